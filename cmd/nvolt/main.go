@@ -1,14 +1,21 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/iluxav/nvolt/internal/cli"
+	"github.com/iluxav/nvolt/internal/process"
 )
 
 func main() {
 	if err := cli.Execute(); err != nil {
+		// Exit like the command started by 'nvolt run'
+		var exitErr *process.ExitError
+		if errors.As(err, &exitErr) {
+			exitErr.Exit()
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
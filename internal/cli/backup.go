@@ -0,0 +1,352 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/iluxav/nvolt/internal/crypto"
+	"github.com/iluxav/nvolt/internal/git"
+	"github.com/iluxav/nvolt/internal/ui"
+	"github.com/iluxav/nvolt/internal/validation"
+	"github.com/iluxav/nvolt/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Create and restore offline vault backups",
+	Long: `Create and restore single-file archives of machines, wrapped keys and
+encrypted secrets, independent of the Git repository.`,
+}
+
+var backupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a backup archive",
+	Long: `Write the machines, wrapped keys and encrypted secrets of the vault to a
+single archive with a checksum for every file.
+
+Secrets stay encrypted in the archive. With --backup-key, the master key of
+every environment is also wrapped for a dedicated backup public key, so the
+backup can be restored even if every registered machine is lost. This requires
+access to all environments being backed up.
+
+Examples:
+  nvolt backup create
+  nvolt backup create -o vault.tar.gz -e production
+  nvolt backup create -p api -p web --backup-key nvolt-backup-key.pub.pem`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		projects, _ := cmd.Flags().GetStringSlice("project")
+		environments, _ := cmd.Flags().GetStringSlice("env")
+		backupKey, _ := cmd.Flags().GetString("backup-key")
+		return runBackupCreate(output, projects, environments, backupKey)
+	},
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore [archive]",
+	Short: "Restore a backup archive",
+	Long: `Verify a backup archive and restore it into a local or global vault.
+
+Machine lists are merged and environments that already exist in the target are
+rejected before anything is written. With --backup-key, the current machine is
+granted access to every restored environment using the backup private key.
+
+Examples:
+  nvolt backup restore vault.tar.gz
+  nvolt backup restore vault.tar.gz --to local
+  nvolt backup restore vault.tar.gz --to org/repo -p myproject --backup-key nvolt-backup-key.pem`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		to, _ := cmd.Flags().GetString("to")
+		project, _ := cmd.Flags().GetString("project")
+		backupKey, _ := cmd.Flags().GetString("backup-key")
+		return runBackupRestore(args[0], to, project, backupKey)
+	},
+}
+
+var backupKeygenCmd = &cobra.Command{
+	Use:   "keygen",
+	Short: "Generate a backup keypair",
+	Long: `Generate an RSA keypair for --backup-key.
+
+The public key (<name>.pub.pem) is used with 'backup create'. Keep the private
+key (<name>.pem) offline; it can restore access to every environment in
+backups made with the public key.
+
+Examples:
+  nvolt backup keygen
+  nvolt backup keygen -o ~/offline/nvolt-backup`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		output, _ := cmd.Flags().GetString("output")
+		return runBackupKeygen(output)
+	},
+}
+
+func runBackupKeygen(name string) error {
+	privatePath, publicPath := name+".pem", name+".pub.pem"
+	for _, p := range []string{privatePath, publicPath} {
+		if vault.FileExists(p) {
+			return fmt.Errorf("%s already exists", p)
+		}
+	}
+
+	ui.Step("Generating backup keypair")
+	privateKey, err := crypto.GenerateRSAKeypair()
+	if err != nil {
+		return err
+	}
+
+	privatePEM, err := crypto.EncodePrivateKeyPEM(privateKey)
+	if err != nil {
+		return err
+	}
+	publicPEM, err := crypto.EncodePublicKeyPEM(&privateKey.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	if err := vault.WriteFileAtomic(privatePath, privatePEM, vault.PrivateKeyPerm); err != nil {
+		return fmt.Errorf("failed to write private key: %w", err)
+	}
+	if err := vault.WriteFileAtomic(publicPath, publicPEM, vault.FilePerm); err != nil {
+		return fmt.Errorf("failed to write public key: %w", err)
+	}
+
+	ui.Success("Backup keypair generated")
+	ui.PrintKeyValue("  Public key", ui.Cyan(publicPath))
+	ui.PrintKeyValue("  Private key", ui.Cyan(privatePath))
+	ui.Warning("Store the private key offline: anyone holding it can decrypt backups made with the public key")
+
+	return nil
+}
+
+func runBackupCreate(output string, projects, environments []string, backupKeyPath string) error {
+	vaultPath, err := findVaultPath()
+	if err != nil {
+		return err
+	}
+
+	if vault.IsGlobalMode(vaultPath) {
+		repoPath := vault.GetRepoPathFromVault(vaultPath)
+		ui.Step("Pulling latest changes from repository")
+		if err := git.SafePull(repoPath); err != nil {
+			return fmt.Errorf("failed to pull latest changes: %w", err)
+		}
+		ui.Success("Repository up to date")
+	} else if len(projects) > 0 {
+		return fmt.Errorf("--project is only supported for global vaults")
+	}
+
+	warnIfVaultNewer(vaultPath)
+
+	opts := vault.BackupOptions{Projects: projects, Environments: environments}
+
+	if machineInfo, err := vault.LoadMachineInfo(); err == nil {
+		opts.CreatedBy = machineInfo.ID
+	}
+
+	if backupKeyPath != "" {
+		data, err := os.ReadFile(backupKeyPath)
+		if err != nil {
+			return fmt.Errorf("failed to read backup key: %w", err)
+		}
+		if opts.BackupKey, err = crypto.DecodePublicKeyPEM(data); err != nil {
+			return fmt.Errorf("failed to parse backup key: %w", err)
+		}
+	}
+
+	if output == "" {
+		output = fmt.Sprintf("nvolt-backup-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+	if vault.FileExists(output) {
+		return fmt.Errorf("%s already exists", output)
+	}
+
+	ui.Step("Creating backup")
+	var archive bytes.Buffer
+	manifest, err := vault.CreateBackup(&archive, vaultPath, opts)
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+
+	if err := vault.WriteFileAtomic(output, archive.Bytes(), vault.PrivateKeyPerm); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	ui.Success("Backup written to %s", ui.Cyan(output))
+	printBackupManifest(manifest)
+
+	return nil
+}
+
+func runBackupRestore(file, to, project, backupKeyPath string) error {
+	if err := EnsureMachineInitialized(); err != nil {
+		return err
+	}
+
+	if project != "" {
+		if err := validation.ValidateProjectName(project); err != nil {
+			return err
+		}
+	}
+
+	ui.Step("Verifying backup")
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	backup, err := vault.ReadBackup(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	ui.Success("Backup integrity verified")
+	printBackupManifest(backup.Manifest)
+
+	opts := vault.RestoreOptions{Project: project}
+	if backupKeyPath != "" {
+		data, err := os.ReadFile(backupKeyPath)
+		if err != nil {
+			return fmt.Errorf("failed to read backup key: %w", err)
+		}
+		if opts.BackupKey, err = crypto.DecodePrivateKeyPEM(data); err != nil {
+			return fmt.Errorf("failed to parse backup key: %w", err)
+		}
+		if opts.Machine, err = vault.LoadMachineInfo(); err != nil {
+			return fmt.Errorf("failed to load machine info: %w", err)
+		}
+	}
+
+	var vaultPath string
+	switch to {
+	case "":
+		if vaultPath, err = findVaultPath(); err != nil {
+			return fmt.Errorf("%w (use --to local or --to org/repo to choose a target)", err)
+		}
+	case "local":
+		if vaultPath, err = vault.GetLocalVaultPath(); err != nil {
+			return fmt.Errorf("failed to get local vault path: %w", err)
+		}
+	default:
+		if vaultPath, err = getClonedGlobalVault(to); err != nil {
+			return err
+		}
+	}
+
+	unlock, err := lockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if vault.IsGlobalMode(vaultPath) {
+		repoPath := vault.GetRepoPathFromVault(vaultPath)
+		ui.Step("Pulling latest changes from repository")
+		if err := git.SafePull(repoPath); err != nil {
+			return fmt.Errorf("failed to pull latest changes: %w", err)
+		}
+		ui.Success("Repository up to date")
+	}
+
+	if vault.IsVaultInitialized(vaultPath) {
+		if err := ensureVaultWritable(vaultPath); err != nil {
+			return err
+		}
+	} else {
+		if err := vault.InitializeVaultDirectory(vaultPath); err != nil {
+			return fmt.Errorf("failed to initialize vault directory: %w", err)
+		}
+		if err := vault.SaveVaultConfig(vaultPath, vault.NewVaultConfig(vaultPath)); err != nil {
+			return err
+		}
+	}
+
+	ui.Step("Restoring backup into %s", ui.Gray(vaultPath))
+	result, err := vault.RestoreBackup(backup, vaultPath, opts)
+	if err != nil {
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+
+	ui.Success("Restored %d secret(s) across %d environment(s)", result.Secrets, result.Environments)
+	ui.Success("Merged machines: %d added, %d already registered", len(result.MachinesAdded), len(result.MachinesSkipped))
+	for _, granted := range result.Granted {
+		ui.Substep(fmt.Sprintf("Granted this machine access to %s", ui.Cyan(granted)))
+	}
+
+	// Verify every restored secret decrypts for this machine
+	ui.Step("Verifying restored secrets")
+	for i, p := range backup.Manifest.Projects {
+		paths := vault.GetVaultPaths(vaultPath, result.Projects[i])
+		for _, env := range p.Environments {
+			name := path.Join(result.Projects[i], env)
+			count, err := vault.VerifyEnvironmentDecrypts(paths, env)
+			if err != nil {
+				if errors.Is(err, vault.ErrAccessDenied) {
+					ui.Warning("Cannot verify '%s': this machine has no access to the environment", name)
+					continue
+				}
+				return fmt.Errorf("verification failed for '%s': %w", name, err)
+			}
+			ui.Info(fmt.Sprintf("  %s %s (%d secret(s))", ui.BrightGreen("✓"), ui.Cyan(name), count))
+		}
+	}
+
+	if vault.IsGlobalMode(vaultPath) {
+		repoPath := vault.GetRepoPathFromVault(vaultPath)
+		ui.Step("Committing and pushing changes to repository")
+		commitMsg := fmt.Sprintf("Restore backup from %s", backup.Manifest.CreatedAt.Format(time.RFC3339))
+		if err := git.CommitAndPush(repoPath, commitMsg, append(result.Projects, vault.MachinesDir)...); err != nil {
+			return fmt.Errorf("failed to commit and push changes: %w", err)
+		}
+		ui.Success("Changes committed and pushed")
+	}
+
+	return nil
+}
+
+// printBackupManifest prints a summary of a backup's contents
+func printBackupManifest(manifest *vault.BackupManifest) {
+	ui.PrintKeyValue("  Created", manifest.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	if manifest.CreatedBy != "" {
+		ui.PrintKeyValue("  Created by", ui.Cyan(manifest.CreatedBy))
+	}
+	if manifest.Repository != "" {
+		ui.PrintKeyValue("  Repository", manifest.Repository)
+	}
+	for _, p := range manifest.Projects {
+		name := p.Name
+		if name == "" {
+			name = "(local)"
+		}
+		ui.PrintKeyValue("  "+name, strings.Join(p.Environments, ", "))
+	}
+	if manifest.BackupKeyFingerprint != "" {
+		ui.PrintKeyValue("  Backup key", ui.Gray(manifest.BackupKeyFingerprint))
+	}
+}
+
+func init() {
+	backupCreateCmd.Flags().StringP("output", "o", "", "Archive path (default nvolt-backup-<timestamp>.tar.gz)")
+	backupCreateCmd.Flags().StringSliceP("project", "p", nil, "Projects to back up (default: all)")
+	backupCreateCmd.Flags().StringSliceP("env", "e", nil, "Environments to back up (default: all)")
+	backupCreateCmd.Flags().String("backup-key", "", "PEM public key to also wrap every master key for")
+
+	backupRestoreCmd.Flags().String("to", "", "Target: 'local' or a global repository (org/repo); defaults to the current vault")
+	backupRestoreCmd.Flags().StringP("project", "p", "", "Target project for a single-project backup restored into a global vault")
+	backupRestoreCmd.Flags().String("backup-key", "", "PEM backup private key used to grant this machine access")
+
+	backupKeygenCmd.Flags().StringP("output", "o", "nvolt-backup-key", "Base name of the key files")
+
+	backupCmd.AddCommand(backupKeygenCmd)
+	backupCmd.AddCommand(backupCreateCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+	rootCmd.AddCommand(backupCmd)
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"path"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// buildChildEnv returns the environment of a command: the inherited variables
+// (only those matching keep if isolated) followed by the secrets. A secret
+// replaces an inherited variable of the same name; those whose value differs
+// are returned as shadowed.
+func buildChildEnv(inherited []string, secrets map[string]string, isolated bool, keep []string) ([]string, []string) {
+	byName := make(map[string]string, len(secrets))
+	for key, value := range secrets {
+		byName[envName(key)] = value
+	}
+
+	env := make([]string, 0, len(inherited)+len(secrets))
+	var shadowed []string
+	for _, entry := range inherited {
+		name, value, _ := strings.Cut(entry, "=")
+		// Windows keeps per-drive directories in variables like "=C:"
+		if name == "" {
+			if !isolated {
+				env = append(env, entry)
+			}
+			continue
+		}
+
+		if isolated && !keepVariable(keep, name) {
+			continue
+		}
+		if secret, ok := byName[envName(name)]; ok {
+			if secret != value && !slices.Contains(shadowed, name) {
+				shadowed = append(shadowed, name)
+			}
+			continue
+		}
+		env = append(env, entry)
+	}
+
+	keys := make([]string, 0, len(secrets))
+	for key := range secrets {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		env = append(env, key+"="+secrets[key])
+	}
+
+	slices.Sort(shadowed)
+	return env, shadowed
+}
+
+// envName normalizes a variable name for comparison: names are
+// case-insensitive on Windows
+func envName(name string) string {
+	if runtime.GOOS == "windows" {
+		return strings.ToUpper(name)
+	}
+	return name
+}
+
+// keepVariable reports whether name matches one of the --keep patterns
+func keepVariable(keep []string, name string) bool {
+	for _, pattern := range keep {
+		if ok, _ := path.Match(envName(pattern), envName(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// envHas reports whether env sets the variable name
+func envHas(env []string, name string) bool {
+	for _, entry := range env {
+		if n, _, _ := strings.Cut(entry, "="); envName(n) == envName(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBuildChildEnv(t *testing.T) {
+	inherited := []string{"PATH=/bin", "HOME=/root", "API_KEY=stale", "DB_HOST=db", "LC_ALL=C"}
+	secrets := map[string]string{"API_KEY": "fresh", "DB_HOST": "db"}
+
+	// Secrets replace inherited variables instead of adding duplicates
+	env, shadowed := buildChildEnv(inherited, secrets, false, nil)
+	want := []string{"PATH=/bin", "HOME=/root", "LC_ALL=C", "API_KEY=fresh", "DB_HOST=db"}
+	if !slices.Equal(env, want) {
+		t.Errorf("env = %v, want %v", env, want)
+	}
+	if !slices.Equal(shadowed, []string{"API_KEY"}) {
+		t.Errorf("shadowed = %v, want [API_KEY]", shadowed)
+	}
+
+	env, _ = buildChildEnv(inherited, secrets, true, []string{"PATH", "LC_*"})
+	want = []string{"PATH=/bin", "LC_ALL=C", "API_KEY=fresh", "DB_HOST=db"}
+	if !slices.Equal(env, want) {
+		t.Errorf("isolated env = %v, want %v", env, want)
+	}
+}
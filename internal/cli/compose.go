@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/iluxav/nvolt/internal/ui"
+	"github.com/iluxav/nvolt/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+// conflictPolicy decides which project wins when composed projects set the
+// same key to different values
+type conflictPolicy string
+
+const (
+	conflictLast  conflictPolicy = "last"  // later projects override earlier ones
+	conflictFirst conflictPolicy = "first" // earlier projects are kept
+	conflictError conflictPolicy = "error" // composition fails
+)
+
+var conflictPolicies = []string{string(conflictLast), string(conflictFirst), string(conflictError)}
+
+// parseConflictPolicy parses the value of --on-conflict
+func parseConflictPolicy(value string) (conflictPolicy, error) {
+	if !slices.Contains(conflictPolicies, value) {
+		return "", fmt.Errorf("invalid --on-conflict '%s': must be one of %s", value, strings.Join(conflictPolicies, ", "))
+	}
+	return conflictPolicy(value), nil
+}
+
+// composeOptions control how the secrets of several projects are combined
+type composeOptions struct {
+	onConflict conflictPolicy
+}
+
+// addComposeFlags adds the composition flags to a command loading secrets
+func addComposeFlags(cmd *cobra.Command) {
+	cmd.Flags().String("on-conflict", string(conflictLast), "When projects set a key differently: "+strings.Join(conflictPolicies, ", "))
+}
+
+// getComposeOptions reads the flags added by addComposeFlags
+func getComposeOptions(cmd *cobra.Command) (composeOptions, error) {
+	var opts composeOptions
+	onConflict, _ := cmd.Flags().GetString("on-conflict")
+
+	var err error
+	if opts.onConflict, err = parseConflictPolicy(onConflict); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// secretLayer is the secrets of one composed project
+type secretLayer struct {
+	project string
+	prefix  string            // added to every key when composing
+	secrets map[string]string // keys without the prefix
+}
+
+// secretConflict is a key set to different values by several projects
+type secretConflict struct {
+	key      string
+	projects []string // projects setting the key, in composition order
+	winner   string
+}
+
+// composeSecrets merges layers in order according to policy. Keys set to the
+// same value by several projects are not conflicts.
+func composeSecrets(layers []secretLayer, policy conflictPolicy) (map[string]string, []secretConflict) {
+	merged := make(map[string]string)
+	owner := make(map[string]string)
+	conflicts := make(map[string]*secretConflict)
+
+	for _, layer := range layers {
+		for key, value := range prefixKeys(layer.secrets, layer.prefix) {
+			current, exists := merged[key]
+			if !exists {
+				merged[key] = value
+				owner[key] = layer.project
+				continue
+			}
+			if current == value {
+				continue
+			}
+
+			c := conflicts[key]
+			if c == nil {
+				c = &secretConflict{key: key, projects: []string{owner[key]}}
+				conflicts[key] = c
+			}
+			c.projects = append(c.projects, layer.project)
+
+			if policy != conflictFirst {
+				merged[key] = value
+				owner[key] = layer.project
+			}
+		}
+	}
+
+	result := make([]secretConflict, 0, len(conflicts))
+	for key, c := range conflicts {
+		c.winner = owner[key]
+		result = append(result, *c)
+	}
+	slices.SortFunc(result, func(a, b secretConflict) int {
+		return strings.Compare(a.key, b.key)
+	})
+	return merged, result
+}
+
+// resolveLayerReferences resolves the references of every layer. A ${KEY}
+// reference is resolved against the layer's own secrets first, and otherwise
+// against the composed secrets of all layers (with their prefixes), in the
+// layer that provides the key. Only composed keys for which required returns
+// true fail on unresolvable references; others keep their raw value.
+func resolveLayerReferences(layers []secretLayer, policy conflictPolicy, load vault.ProjectLoader, required func(key string) bool) ([]secretLayer, error) {
+	// owner maps each composed key to the layer providing it
+	owner := make(map[string]int)
+	for i, layer := range layers {
+		for key := range layer.secrets {
+			if _, exists := owner[layer.prefix+key]; !exists || policy != conflictFirst {
+				owner[layer.prefix+key] = i
+			}
+		}
+	}
+	fallback := func(key string) (string, map[string]string, string, bool) {
+		i, ok := owner[key]
+		if !ok {
+			return "", nil, "", false
+		}
+		return layers[i].project, layers[i].secrets, strings.TrimPrefix(key, layers[i].prefix), true
+	}
+
+	resolved := make([]secretLayer, len(layers))
+	for i, layer := range layers {
+		// Values are cached per scope, and "" is a different layer each time
+		in := vault.NewInterpolator(load)
+		in.SetFallback(fallback)
+
+		optional := func(key string) bool { return !required(layer.prefix + key) }
+		values, err := in.ResolveKeys(layer.secrets, slices.Collect(maps.Keys(layer.secrets)), optional)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = secretLayer{project: layer.project, prefix: layer.prefix, secrets: values}
+	}
+	return resolved, nil
+}
+
+// reportConflicts warns about conflicting keys, or fails with the error
+// policy. Values are never shown.
+func reportConflicts(conflicts []secretConflict, policy conflictPolicy) error {
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	if policy == conflictError {
+		details := make([]string, len(conflicts))
+		for i, c := range conflicts {
+			details[i] = fmt.Sprintf("%s (%s)", c.key, strings.Join(c.projects, ", "))
+		}
+		return fmt.Errorf("composed projects set different values for %s\nChoose a winner with --on-conflict first|last, or prefix a project's keys with -p project:PREFIX_", strings.Join(details, ", "))
+	}
+
+	ui.Warning("%d secret(s) set differently by several projects:", len(conflicts))
+	for _, c := range conflicts {
+		ui.Substep(fmt.Sprintf("%s: %s (using %s)", c.key, strings.Join(c.projects, ", "), ui.Cyan(c.winner)))
+	}
+	return nil
+}
+
+// splitProjectPrefix splits "project:PREFIX_" (or "org/repo:project:PREFIX_")
+// into the project spec and the prefix added to its keys
+func splitProjectPrefix(spec string) (string, string) {
+	i := strings.LastIndex(spec, ":")
+	if i < 0 {
+		return spec, ""
+	}
+	// "org/repo:project" names a vault, not a prefix
+	if before := spec[:i]; !strings.Contains(before, ":") && strings.Contains(before, "/") {
+		return spec, ""
+	}
+	return spec[:i], spec[i+1:]
+}
+
+// prefixKeys returns secrets with prefix added to every key
+func prefixKeys(secrets map[string]string, prefix string) map[string]string {
+	if prefix == "" {
+		return secrets
+	}
+	prefixed := make(map[string]string, len(secrets))
+	for key, value := range secrets {
+		prefixed[prefix+key] = value
+	}
+	return prefixed
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestComposeSecrets(t *testing.T) {
+	layers := []secretLayer{
+		{project: "db", secrets: map[string]string{"DB_HOST": "db1", "REGION": "eu"}},
+		{project: "api", secrets: map[string]string{"DB_HOST": "db2", "REGION": "eu", "PORT": "80"}},
+	}
+
+	tests := []struct {
+		policy conflictPolicy
+		want   string
+		winner string
+	}{
+		{conflictLast, "db2", "api"},
+		{conflictFirst, "db1", "db"},
+	}
+
+	for _, tt := range tests {
+		merged, conflicts := composeSecrets(layers, tt.policy)
+		if merged["DB_HOST"] != tt.want || len(merged) != 3 {
+			t.Errorf("%s: merged = %v, want DB_HOST=%s and 3 keys", tt.policy, merged, tt.want)
+		}
+
+		// REGION has the same value in both projects and is no conflict
+		if len(conflicts) != 1 || conflicts[0].key != "DB_HOST" || conflicts[0].winner != tt.winner ||
+			!slices.Equal(conflicts[0].projects, []string{"db", "api"}) {
+			t.Errorf("%s: conflicts = %+v, want DB_HOST from db, api won by %s", tt.policy, conflicts, tt.winner)
+		}
+	}
+
+	if err := reportConflicts([]secretConflict{{key: "DB_HOST"}}, conflictError); err == nil {
+		t.Error("reportConflicts with the error policy succeeded, want an error")
+	}
+}
+
+func TestResolveLayerReferencesWithPrefix(t *testing.T) {
+	layers := []secretLayer{
+		{project: "db", secrets: map[string]string{"HOST": "dbhost", "DB_URL": "pg://${HOST}"}},
+		{project: "pay", prefix: "PAY_", secrets: map[string]string{"HOST": "payhost", "URL": "pg://${HOST}", "DB": "${DB_URL}"}},
+	}
+
+	resolved, err := resolveLayerReferences(layers, conflictLast, nil, keySelection{}.selects)
+	if err != nil {
+		t.Fatalf("resolveLayerReferences failed: %v", err)
+	}
+	merged, conflicts := composeSecrets(resolved, conflictLast)
+
+	// A prefixed project's references use its own keys first, and the
+	// composed secrets otherwise
+	want := map[string]string{
+		"HOST":     "dbhost",
+		"DB_URL":   "pg://dbhost",
+		"PAY_HOST": "payhost",
+		"PAY_URL":  "pg://payhost",
+		"PAY_DB":   "pg://dbhost",
+	}
+	if !maps.Equal(merged, want) || len(conflicts) != 0 {
+		t.Errorf("merged = %v (conflicts %v), want %v", merged, conflicts, want)
+	}
+
+	// A prefixed project also resolves on its own
+	if _, err := resolveLayerReferences(layers[1:2], conflictLast, nil, keySelection{}.selects); err == nil {
+		t.Error("resolving ${DB_URL} without the db project succeeded, want an error")
+	}
+	own := []secretLayer{{project: "pay", prefix: "PAY_", secrets: map[string]string{"HOST": "payhost", "URL": "pg://${HOST}"}}}
+	if resolved, err := resolveLayerReferences(own, conflictLast, nil, keySelection{}.selects); err != nil || resolved[0].secrets["URL"] != "pg://payhost" {
+		t.Errorf("resolveLayerReferences(pay) = %v, %v; want URL=pg://payhost", resolved, err)
+	}
+}
+
+func TestResolveLayerReferencesSelected(t *testing.T) {
+	layers := []secretLayer{{project: "api", secrets: map[string]string{
+		"A":      "${B}",
+		"B":      "b",
+		"BROKEN": "${MISSING}",
+	}}}
+
+	// A broken reference only matters if its key is selected
+	sel := keySelection{only: []string{"A"}}
+	resolved, err := resolveLayerReferences(layers, conflictLast, nil, sel.selects)
+	if err != nil {
+		t.Fatalf("resolveLayerReferences with --only A failed: %v", err)
+	}
+	if resolved[0].secrets["A"] != "b" {
+		t.Errorf("A = %q, want %q", resolved[0].secrets["A"], "b")
+	}
+
+	if _, err := resolveLayerReferences(layers, conflictLast, nil, keySelection{}.selects); err == nil {
+		t.Error("resolveLayerReferences without a selection succeeded, want an error for BROKEN")
+	}
+}
+
+func TestPrefixKeys(t *testing.T) {
+	got := prefixKeys(map[string]string{"HOST": "h", "KEY": "k"}, "PAY_")
+	if want := map[string]string{"PAY_HOST": "h", "PAY_KEY": "k"}; !maps.Equal(got, want) {
+		t.Errorf("prefixKeys = %v, want %v", got, want)
+	}
+}
+
+func TestSplitProjectPrefix(t *testing.T) {
+	tests := []struct {
+		spec       string
+		wantSpec   string
+		wantPrefix string
+	}{
+		{"api", "api", ""},
+		{"payments:PAY_", "payments", "PAY_"},
+		{"acme/infra:db", "acme/infra:db", ""},
+		{"acme/infra:db:DB_", "acme/infra:db", "DB_"},
+	}
+
+	for _, tt := range tests {
+		spec, prefix := splitProjectPrefix(tt.spec)
+		if spec != tt.wantSpec || prefix != tt.wantPrefix {
+			t.Errorf("splitProjectPrefix(%q) = %q, %q; want %q, %q", tt.spec, spec, prefix, tt.wantSpec, tt.wantPrefix)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/iluxav/nvolt/internal/config"
+	"github.com/iluxav/nvolt/internal/git"
+	"github.com/iluxav/nvolt/internal/ui"
+	"github.com/iluxav/nvolt/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage user defaults and policies",
+	Long: `Read and change the user configuration in ~/.nvolt/config.toml
+($NVOLT_CONFIG/config.toml when NVOLT_CONFIG is set).
+
+Settings apply to every command unless overridden. Precedence, highest first:
+command-line flags, environment variables, config.toml, built-in defaults.
+
+Examples:
+  nvolt config list
+  nvolt config set defaults.environment production
+  nvolt config get git.push
+  nvolt config unset defaults.environment`,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all settings with their effective values",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigList()
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the effective value of a setting",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigGet(args[0])
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a setting in config.toml",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigSet(args[0], args[1])
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a setting from config.toml",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigSet(args[0], "")
+	},
+}
+
+func runConfigList() error {
+	var rows [][]string
+	for _, key := range config.UserConfigKeys {
+		value, source := userConfig.Resolve(key.Name)
+		if value == "" {
+			value = ui.Gray("(none)")
+		}
+		rows = append(rows, []string{key.Name, value, source, key.Description})
+	}
+
+	fmt.Println(headerStyle.Render("Settings"))
+	fmt.Println(renderTable([]string{"Key", "Value", "Source", "Description"}, rows))
+
+	return nil
+}
+
+func runConfigGet(name string) error {
+	if _, err := config.LookupUserConfigKey(name); err != nil {
+		return err
+	}
+
+	value, _ := userConfig.Resolve(name)
+	fmt.Println(value)
+	return nil
+}
+
+func runConfigSet(name, value string) error {
+	key, err := config.LookupUserConfigKey(name)
+	if err != nil {
+		return err
+	}
+
+	path, err := userConfigPath()
+	if err != nil {
+		return err
+	}
+
+	// Load strictly: a broken file must not be overwritten
+	cfg, err := config.LoadUserConfig(path)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Set(name, value); err != nil {
+		return err
+	}
+
+	if err := config.SaveUserConfig(path, cfg); err != nil {
+		return err
+	}
+
+	if value == "" {
+		ui.Success("Removed %s", name)
+	} else {
+		ui.Success("Set %s = %s", name, value)
+	}
+
+	if key.EnvVar != "" && os.Getenv(key.EnvVar) != "" {
+		ui.Warning("%s is set and overrides this setting", key.EnvVar)
+	}
+
+	return nil
+}
+
+// userConfigPath returns the path of config.toml in the nvolt home directory
+func userConfigPath() (string, error) {
+	homePaths, err := vault.GetHomePaths()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homePaths.Root, config.UserConfigFile), nil
+}
+
+// applyUserConfig loads config.toml and applies it to global settings and to
+// the flags of cmd that were not set on the command line
+func applyUserConfig(cmd *cobra.Command) {
+	if path, err := userConfigPath(); err == nil {
+		cfg, err := config.LoadUserConfig(path)
+		if err != nil {
+			ui.Warning("Ignoring user config: %v", err)
+		} else {
+			userConfig = cfg
+		}
+	}
+
+	// --no-color and NO_COLOR win over ui.color
+	if !noColor && os.Getenv("NO_COLOR") == "" {
+		switch color, _ := userConfig.Resolve("ui.color"); color {
+		case "always":
+			ui.SetColorsEnabled(true)
+		case "never":
+			ui.SetColorsEnabled(false)
+		}
+	}
+
+	git.SetPullEnabled(userConfig.ResolveBool("git.pull"))
+	git.SetPushEnabled(userConfig.ResolveBool("git.push"))
+
+	setFlagDefault(cmd, "env", "defaults.environment")
+	if cmd != importCmd {
+		// defaults.format is an output format; --format of import names its input
+		setFlagDefault(cmd, "format", "defaults.format")
+	}
+	setFlagDefault(cmd, "auto-grant", "policy.auto_grant")
+}
+
+// setFlagDefault replaces the default of an unchanged string or bool flag
+// with a configured value
+func setFlagDefault(cmd *cobra.Command, flagName, key string) {
+	flag := cmd.Flags().Lookup(flagName)
+	if flag == nil || flag.Changed {
+		return
+	}
+	if kind := flag.Value.Type(); kind != "string" && kind != "bool" {
+		return
+	}
+
+	value, source := userConfig.Resolve(key)
+	if source == config.SourceDefault || value == "" {
+		return
+	}
+
+	if err := flag.Value.Set(value); err != nil {
+		ui.Warning("Ignoring %s from %s: %v", key, source, err)
+	}
+}
+
+func init() {
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,278 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/iluxav/nvolt/internal/crypto"
+	"github.com/iluxav/nvolt/internal/git"
+	"github.com/iluxav/nvolt/internal/ui"
+	"github.com/iluxav/nvolt/internal/validation"
+	"github.com/iluxav/nvolt/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Manage environments",
+	Long:  `List, copy, rename and delete environments.`,
+}
+
+var envListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all environments",
+	Long: `List all environments with their secret count and the machines that have access.
+
+Examples:
+  nvolt env list
+  nvolt env list -p myproject`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, _ := cmd.Flags().GetString("project")
+		return runEnvList(project)
+	},
+}
+
+var envCpCmd = &cobra.Command{
+	Use:   "cp [source] [target]",
+	Short: "Copy an environment",
+	Long: `Copy all secrets of an environment into a new environment.
+
+Secrets are re-encrypted under a fresh master key, which is granted to the
+same machines that have access to the source environment.
+
+Examples:
+  nvolt env cp staging production
+  nvolt env cp staging qa -p myproject`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, _ := cmd.Flags().GetString("project")
+		return runEnvCp(args[0], args[1], project)
+	},
+}
+
+var envMvCmd = &cobra.Command{
+	Use:   "mv [source] [target]",
+	Short: "Rename an environment",
+	Long: `Rename an environment, moving its secrets and wrapped keys.
+
+Examples:
+  nvolt env mv stage staging
+  nvolt env mv stage staging -p myproject`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, _ := cmd.Flags().GetString("project")
+		return runEnvMv(args[0], args[1], project)
+	},
+}
+
+var envRmCmd = &cobra.Command{
+	Use:   "rm [environment]",
+	Short: "Delete an environment",
+	Long: `Delete an environment with all of its secrets and wrapped keys.
+
+Examples:
+  nvolt env rm qa
+  nvolt env rm qa -p myproject --yes`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, _ := cmd.Flags().GetString("project")
+		yes, _ := cmd.Flags().GetBool("yes")
+		return runEnvRm(args[0], project, yes)
+	},
+}
+
+func runEnvList(project string) error {
+	vaultPath, project, unlock, err := prepareVault(project, false)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	paths := vault.GetVaultPaths(vaultPath, project)
+	environments, err := vault.ListEnvironments(paths)
+	if err != nil {
+		return fmt.Errorf("failed to list environments: %w", err)
+	}
+
+	if len(environments) == 0 {
+		ui.Info(ui.Gray("Environments: (none)"))
+		return nil
+	}
+
+	ui.Section(fmt.Sprintf("Environments (%d):", len(environments)))
+	for _, env := range environments {
+		secretFiles, err := paths.Storage().ListFiles(paths.GetSecretsPath(env))
+		if err != nil {
+			return fmt.Errorf("failed to list secrets for environment '%s': %w", env, err)
+		}
+		machineIDs, err := vault.ListEnvironmentMachines(paths, env)
+		if err != nil {
+			return fmt.Errorf("failed to list machines for environment '%s': %w", env, err)
+		}
+		ui.Substep(fmt.Sprintf("%s (%d secret(s), %d machine(s))", ui.Cyan(env), len(secretFiles), len(machineIDs)))
+	}
+
+	return nil
+}
+
+func runEnvCp(source, target, project string) error {
+	if err := EnsureMachineInitialized(); err != nil {
+		return err
+	}
+	if err := validation.ValidateEnvironmentNames(source, target); err != nil {
+		return err
+	}
+
+	ui.Step("Copying environment '%s' to '%s'", ui.Cyan(source), ui.Cyan(target))
+
+	vaultPath, project, unlock, err := prepareVault(project, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	paths := vault.GetVaultPaths(vaultPath, project)
+	if !vault.EnvironmentExists(paths, source) {
+		return fmt.Errorf("environment '%s' not found", source)
+	}
+
+	machineInfo, err := vault.LoadMachineInfo()
+	if err != nil {
+		return fmt.Errorf("failed to load machine info: %w", err)
+	}
+
+	sourceKey, err := vault.UnwrapMasterKey(paths, source)
+	if err != nil {
+		return fmt.Errorf("failed to unwrap master key: %w", err)
+	}
+	defer crypto.ZeroBytes(sourceKey)
+
+	targetKey, err := crypto.GenerateAESKey()
+	if err != nil {
+		return fmt.Errorf("failed to generate master key: %w", err)
+	}
+	defer crypto.ZeroBytes(targetKey)
+
+	tx, err := vault.BeginTransaction(vaultPath, fmt.Sprintf("copy environment %s to %s", source, target))
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	granted, err := vault.CopyEnvironment(paths.WithTransaction(tx), source, target, sourceKey, targetKey, machineInfo.ID)
+	if err != nil {
+		return fmt.Errorf("failed to copy environment: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to copy environment: %w", err)
+	}
+
+	ui.Success("Copied '%s' to '%s' under a new master key", ui.Cyan(source), ui.Cyan(target))
+	ui.Section(fmt.Sprintf("Access granted to %d machine(s):", len(granted)))
+	for _, machineID := range granted {
+		ui.Substep(ui.Cyan(machineID))
+	}
+
+	return commitEnvChanges(vaultPath, project, fmt.Sprintf("Copy environment '%s' to '%s'", source, target))
+}
+
+func runEnvMv(source, target, project string) error {
+	if err := validation.ValidateEnvironmentNames(source, target); err != nil {
+		return err
+	}
+
+	ui.Step("Renaming environment '%s' to '%s'", ui.Cyan(source), ui.Cyan(target))
+
+	vaultPath, project, unlock, err := prepareVault(project, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	paths := vault.GetVaultPaths(vaultPath, project)
+	if err := vault.RenameEnvironment(paths, source, target); err != nil {
+		return fmt.Errorf("failed to rename environment: %w", err)
+	}
+
+	ui.Success("Renamed '%s' to '%s'", ui.Cyan(source), ui.Cyan(target))
+
+	return commitEnvChanges(vaultPath, project, fmt.Sprintf("Rename environment '%s' to '%s'", source, target))
+}
+
+func runEnvRm(environment, project string, yes bool) error {
+	if err := validation.ValidateEnvironmentNames(environment); err != nil {
+		return err
+	}
+
+	vaultPath, project, unlock, err := prepareVault(project, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	paths := vault.GetVaultPaths(vaultPath, project)
+	if !vault.EnvironmentExists(paths, environment) {
+		return fmt.Errorf("environment '%s' not found", environment)
+	}
+
+	secretFiles, err := paths.Storage().ListFiles(paths.GetSecretsPath(environment))
+	if err != nil {
+		return fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	// Confirm removal
+	if !yes {
+		fmt.Printf("\n%s %s (%d secret(s))? This cannot be undone. (yes/no): ",
+			ui.Yellow("Are you sure you want to delete environment"), ui.Cyan(environment), len(secretFiles))
+		var response string
+		fmt.Scanln(&response)
+		if response != "yes" {
+			ui.Warning("Aborted")
+			return nil
+		}
+	}
+
+	tx, err := vault.BeginTransaction(vaultPath, fmt.Sprintf("delete environment %s", environment))
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := vault.DeleteEnvironment(paths.WithTransaction(tx), environment); err != nil {
+		return fmt.Errorf("failed to delete environment: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to delete environment: %w", err)
+	}
+
+	ui.Success("Deleted environment '%s'", ui.Cyan(environment))
+
+	return commitEnvChanges(vaultPath, project, fmt.Sprintf("Delete environment '%s'", environment))
+}
+
+// commitEnvChanges commits and pushes environment changes in global mode
+func commitEnvChanges(vaultPath, project, commitMsg string) error {
+	if !vault.IsGlobalMode(vaultPath) {
+		return nil
+	}
+
+	repoPath := vault.GetRepoPathFromVault(vaultPath)
+	ui.Step("Committing and pushing changes to repository")
+
+	if err := git.CommitAndPush(repoPath, fmt.Sprintf("%s in project '%s'", commitMsg, project), project); err != nil {
+		return fmt.Errorf("failed to commit and push changes: %w", err)
+	}
+
+	ui.Success("Changes committed and pushed")
+	return nil
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{envListCmd, envCpCmd, envMvCmd, envRmCmd} {
+		cmd.Flags().StringP("project", "p", "", "Project name (auto-detected if not specified)")
+		envCmd.AddCommand(cmd)
+	}
+	envRmCmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+
+	rootCmd.AddCommand(envCmd)
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/iluxav/nvolt/internal/crypto"
+	"github.com/iluxav/nvolt/internal/ui"
+	"github.com/iluxav/nvolt/internal/validation"
+	"github.com/iluxav/nvolt/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate <KEY>",
+	Short: "Generate a random secret and store it in the vault",
+	Long: `Generate a random secret value with a cryptographically secure generator
+and push it like 'nvolt push -k KEY=value'. The value is never printed
+unless --show is given.
+
+Types:
+  password     Random characters (--length characters, default 32)
+  hex          Hex-encoded random bytes (--length bytes, default 32)
+  base64       Base64-encoded random bytes (--length bytes, default 32)
+  uuid         Random UUID (version 4)
+  rsa-key      PEM-encoded RSA private key (--length bits, default 4096)
+  ed25519-key  PEM-encoded Ed25519 private key
+
+--charset takes a named set (alnum, alpha, digits, symbols) or the literal
+characters to use for passwords.
+
+Examples:
+  nvolt generate SESSION_SECRET
+  nvolt generate DB_PASSWORD -e production --length 40 --charset alnum
+  nvolt generate API_TOKEN --type hex --if-missing
+  nvolt generate JWT_SIGNING_KEY --type ed25519-key`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		environment, _ := cmd.Flags().GetString("env")
+		project, _ := cmd.Flags().GetString("project")
+		kind, _ := cmd.Flags().GetString("type")
+		length, _ := cmd.Flags().GetInt("length")
+		charset, _ := cmd.Flags().GetString("charset")
+		ifMissing, _ := cmd.Flags().GetBool("if-missing")
+		show, _ := cmd.Flags().GetBool("show")
+
+		return runGenerate(args[0], environment, project, kind, length, charset, ifMissing, show)
+	},
+}
+
+func runGenerate(key, environment, project, kind string, length int, charset string, ifMissing, show bool) error {
+	if err := validation.ValidateSecretKey(key); err != nil {
+		return err
+	}
+
+	// Ensure machine is initialized
+	if err := EnsureMachineInitialized(); err != nil {
+		return err
+	}
+
+	ui.Step("Generating %s secret %s", kind, ui.Cyan(key))
+
+	explicitProject := project
+	vaultPath, project, unlock, err := prepareVault(project, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if ifMissing {
+		paths := vault.GetVaultPaths(vaultPath, project)
+		if paths.Storage().Exists(paths.GetSecretFilePath(environment, key)) {
+			ui.Success("%s already exists in environment '%s', leaving it unchanged", key, environment)
+			return nil
+		}
+	}
+
+	value, err := crypto.GenerateSecret(kind, length, charset)
+	if err != nil {
+		return err
+	}
+
+	secrets := map[string]string{key: value}
+	if err := checkPushedSecrets(explicitProject, environment, secrets); err != nil {
+		return err
+	}
+
+	if err := storeSecrets(vaultPath, project, environment, secrets, false); err != nil {
+		return err
+	}
+
+	if show {
+		fmt.Println(value)
+	}
+
+	return nil
+}
+
+func init() {
+	generateCmd.Flags().StringP("env", "e", "default", "Environment name")
+	generateCmd.Flags().StringP("project", "p", "", "Project name or org/repo:project (auto-detected if not specified)")
+	generateCmd.Flags().StringP("type", "t", crypto.GenPassword, "Type of secret: "+strings.Join(crypto.GeneratorTypes, ", "))
+	generateCmd.Flags().IntP("length", "l", 0, "Length in characters, bytes or bits depending on --type (default: per type)")
+	generateCmd.Flags().String("charset", "", "Password characters: alnum, alpha, digits, symbols or literal characters (default: symbols)")
+	generateCmd.Flags().Bool("if-missing", false, "Do nothing if the secret already exists")
+	generateCmd.Flags().Bool("show", false, "Print the generated value")
+	rootCmd.AddCommand(generateCmd)
+}
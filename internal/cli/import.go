@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/iluxav/nvolt/internal/formats"
+	"github.com/iluxav/nvolt/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <FILE>",
+	Short: "Import secrets from JSON, YAML, Kubernetes Secrets and other formats",
+	Long: `Convert secrets from another file format and push them like 'nvolt push'.
+Nested structures are flattened into keys joined by --separator and
+upper-cased: {"database": {"host": ...}} becomes DATABASE_HOST, list items
+are numbered (HOSTS_0, HOSTS_1). Kubernetes Secret data fields are
+base64-decoded. Use - as FILE to read from stdin.
+
+Formats (detected from the file name when --format is not given):
+  env          .env file
+  json         JSON object
+  yaml         YAML mapping (several documents are merged)
+  k8s-secret   Kubernetes Secret manifest (YAML or JSON)
+  docker-env   docker --env-file (literal values)
+  properties   Java .properties
+  tfvars       Terraform variables
+
+Examples:
+  nvolt import config.json -e production
+  nvolt import secret.yaml --format k8s-secret -e staging
+  nvolt import terraform.tfvars --separator __ --dry-run
+  kubectl get secret app -o yaml | nvolt import - --format k8s-secret`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		environment, _ := cmd.Flags().GetString("env")
+		project, _ := cmd.Flags().GetString("project")
+		format, _ := cmd.Flags().GetString("format")
+		separator, _ := cmd.Flags().GetString("separator")
+		keepCase, _ := cmd.Flags().GetBool("keep-case")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		opts := formats.ImportOptions{Separator: separator, KeepCase: keepCase}
+		return runImport(args[0], format, environment, project, opts, dryRun)
+	},
+}
+
+func runImport(file, format, environment, project string, opts formats.ImportOptions, dryRun bool) error {
+	// Ensure machine is initialized
+	if err := EnsureMachineInitialized(); err != nil {
+		return err
+	}
+
+	secrets, err := readImportFile(file, format, opts)
+	if err != nil {
+		return err
+	}
+	if len(secrets) == 0 {
+		return fmt.Errorf("no secrets found in %s", file)
+	}
+
+	// Preview the keys before anything is written
+	keys := make([]string, 0, len(secrets))
+	for key := range secrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	ui.Section(fmt.Sprintf("Keys to import (%d):", len(keys)))
+	for _, key := range keys {
+		ui.Substep(key)
+	}
+
+	if err := checkPushedSecrets(project, environment, secrets); err != nil {
+		return err
+	}
+
+	if dryRun {
+		ui.Warning("[DRY RUN] Simulating import")
+	}
+
+	vaultPath, project, unlock, err := prepareVault(project, true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return storeSecrets(vaultPath, project, environment, secrets, dryRun)
+}
+
+// readImportFile reads and converts a file, or stdin for "-"
+func readImportFile(file, format string, opts formats.ImportOptions) (map[string]string, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	if format == "" {
+		if file == "-" {
+			return nil, fmt.Errorf("--format is required when reading from stdin")
+		}
+		if format, err = formats.DetectFormat(file, data); err != nil {
+			return nil, err
+		}
+		ui.PrintDetected("Format", format)
+	}
+
+	ui.Step("Importing secrets from %s (%s)", ui.Cyan(file), format)
+	secrets, err := formats.Import(format, data, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import %s: %w", file, err)
+	}
+	return secrets, nil
+}
+
+func init() {
+	importCmd.Flags().StringP("env", "e", "default", "Environment name")
+	importCmd.Flags().StringP("project", "p", "", "Project name or org/repo:project (auto-detected if not specified)")
+	importCmd.Flags().String("format", "", "Input format: "+strings.Join(formats.ImportFormats, ", ")+" (detected from the file name by default)")
+	importCmd.Flags().String("separator", formats.DefaultSeparator, "Separator for the keys of nested values")
+	importCmd.Flags().Bool("keep-case", false, "Keep the case of keys instead of upper-casing them")
+	importCmd.Flags().Bool("dry-run", false, "Show what would be imported without making changes")
+	rootCmd.AddCommand(importCmd)
+}
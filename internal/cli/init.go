@@ -22,18 +22,32 @@ Local mode (current directory):
 Global mode (dedicated GitHub repo):
   nvolt init --repo org/repo
 
+Local vault in a single SQLite database (.nvolt/vault.db):
+  nvolt init --storage sqlite
+
 This command will:
 - Generate an RSA/Ed25519 keypair for this machine (if not exists)
 - Create .nvolt/ directory structure
 - Clone the repo (if --repo provided) into ~/.nvolt/orgs/`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		repo, _ := cmd.Flags().GetString("repo")
+		storage, _ := cmd.Flags().GetString("storage")
+
+		switch storage {
+		case vault.StorageFS:
+		case vault.StorageSQLite:
+			if repo != "" {
+				return fmt.Errorf("--storage %s is only supported in local mode", storage)
+			}
+		default:
+			return fmt.Errorf("unknown storage '%s' (use %s or %s)", storage, vault.StorageFS, vault.StorageSQLite)
+		}
 
-		return runInit(repo)
+		return runInit(repo, storage)
 	},
 }
 
-func runInit(repoSpec string) error {
+func runInit(repoSpec, storage string) error {
 	ui.PrintBanner("Initializing nvolt vault...")
 
 	// Step 1: Ensure machine keypair exists- If not, creates machine config
@@ -56,10 +70,10 @@ func runInit(repoSpec string) error {
 		return initGlobalMode(repoSpec)
 	}
 
-	return initLocalMode()
+	return initLocalMode(storage)
 }
 
-func initLocalMode() error {
+func initLocalMode(storage string) error {
 	ui.PrintModeInfo("Local")
 
 	vaultPath, err := vault.GetLocalVaultPath()
@@ -79,10 +93,22 @@ func initLocalMode() error {
 		ui.Success("Vault already initialized")
 		ui.Substep(ui.Gray(vaultPath))
 
+		// Hold the vault lock while registering the machine
+		unlock, err := lockVault(vaultPath)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		// Refuse to modify vaults written by a newer nvolt
+		if err := ensureVaultWritable(vaultPath); err != nil {
+			return err
+		}
+
 		// Check if current machine is already in the vault
 		paths := vault.GetVaultPaths(vaultPath, "")
 		machinePath := paths.GetMachineInfoPath(machineInfo.ID)
-		if vault.FileExists(machinePath) {
+		if paths.Storage().Exists(machinePath) {
 			ui.Success("Machine already registered in vault")
 		} else {
 			// Add current machine to existing vault
@@ -99,10 +125,20 @@ func initLocalMode() error {
 
 	// Initialize vault directory
 	ui.Step("Creating vault directory")
-	if err := vault.InitializeVaultDirectory(vaultPath); err != nil {
+	if storage == vault.StorageSQLite {
+		err = vault.InitializeSQLiteVault(vaultPath)
+	} else {
+		err = vault.InitializeVaultDirectory(vaultPath)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to initialize vault directory: %w", err)
 	}
 
+	// Record the vault schema version
+	if err := vault.SaveVaultConfig(vaultPath, vault.NewVaultConfig(vaultPath)); err != nil {
+		return err
+	}
+
 	// Add current machine to vault
 	paths := vault.GetVaultPaths(vaultPath, "")
 	if err := vault.AddMachineToVault(paths, machineInfo); err != nil {
@@ -154,6 +190,18 @@ func initGlobalMode(repoSpec string) error {
 		ui.Success("Repository already cloned")
 		ui.Substep(ui.Gray(repoPath))
 
+		// Hold the vault lock while registering the machine
+		unlock, err := lockVault(repoPath)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		// Refuse to modify vaults written by a newer nvolt
+		if err := ensureVaultWritable(repoPath); err != nil {
+			return err
+		}
+
 		// Check if machines directory exists
 		machinesDir := filepath.Join(repoPath, vault.MachinesDir)
 		if !vault.FileExists(machinesDir) {
@@ -209,8 +257,18 @@ func initGlobalMode(repoSpec string) error {
 	ui.Success("Repository cloned")
 	ui.Substep(ui.Gray(repoPath))
 
-	// Initialize machines directory at repo root
+	// A repository without machines or config is a brand new vault
 	machinesDir := filepath.Join(repoPath, vault.MachinesDir)
+	configPath := vault.GetVaultConfigPath(repoPath)
+	if !vault.FileExists(machinesDir) && !vault.FileExists(configPath) {
+		if err := vault.SaveVaultConfig(repoPath, vault.NewVaultConfig(repoPath)); err != nil {
+			return err
+		}
+	} else if err := ensureVaultWritable(repoPath); err != nil {
+		return err
+	}
+
+	// Initialize machines directory at repo root
 	if err := os.MkdirAll(machinesDir, vault.DirPerm); err != nil {
 		return fmt.Errorf("failed to create machines directory: %w", err)
 	}
@@ -221,10 +279,14 @@ func initGlobalMode(repoSpec string) error {
 		return fmt.Errorf("failed to add machine to vault: %w", err)
 	}
 
-	// Commit and push the machine's public key to repository
+	// Commit and push the machine's public key (and config of a new vault) to repository
 	ui.Step("Committing machine to repository")
 	commitMsg := fmt.Sprintf("Add machine %s to vault", machineInfo.ID)
-	if err := git.CommitAndPush(repoPath, commitMsg, "machines"); err != nil {
+	commitPaths := []string{"machines"}
+	if vault.FileExists(configPath) {
+		commitPaths = append(commitPaths, vault.ConfigFile)
+	}
+	if err := git.CommitAndPush(repoPath, commitMsg, commitPaths...); err != nil {
 		return fmt.Errorf("failed to commit and push machine: %w", err)
 	}
 	ui.Success("Machine committed and pushed")
@@ -238,5 +300,6 @@ func initGlobalMode(repoSpec string) error {
 
 func init() {
 	initCmd.Flags().StringP("repo", "r", "", "GitHub repository (org/repo) for global mode")
+	initCmd.Flags().String("storage", vault.StorageFS, "Vault storage for local mode: fs or sqlite")
 	rootCmd.AddCommand(initCmd)
 }
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"github.com/iluxav/nvolt/internal/vault"
 	"github.com/spf13/cobra"
 )
 
@@ -35,7 +36,7 @@ access to specific environments using:
 		}
 
 		// Call the same init logic
-		return runInit(repo)
+		return runInit(repo, vault.StorageFS)
 	},
 }
 
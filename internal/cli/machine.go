@@ -1,9 +1,10 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -88,6 +89,13 @@ func runMachineAdd(machineName string) error {
 		return err
 	}
 
+	// Hold the vault lock for the duration of the command
+	unlock, err := lockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Pull latest changes in global mode BEFORE doing any work
 	if vault.IsGlobalMode(vaultPath) {
 		repoPath := vault.GetRepoPathFromVault(vaultPath)
@@ -98,6 +106,11 @@ func runMachineAdd(machineName string) error {
 		ui.Success("Repository up to date")
 	}
 
+	// Refuse to modify vaults written by a newer nvolt
+	if err := ensureVaultWritable(vaultPath); err != nil {
+		return err
+	}
+
 	// Generate keypair for new machine
 	ui.Step("Generating keypair")
 	privateKey, err := crypto.GenerateRSAKeypair()
@@ -176,6 +189,13 @@ func runMachineRm(machineName string) error {
 		return err
 	}
 
+	// Hold the vault lock for the duration of the command
+	unlock, err := lockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Pull latest changes in global mode BEFORE doing any work
 	if vault.IsGlobalMode(vaultPath) {
 		repoPath := vault.GetRepoPathFromVault(vaultPath)
@@ -186,6 +206,11 @@ func runMachineRm(machineName string) error {
 		ui.Success("Repository up to date")
 	}
 
+	// Refuse to modify vaults written by a newer nvolt
+	if err := ensureVaultWritable(vaultPath); err != nil {
+		return err
+	}
+
 	// List all machines and find matching ones (machines are at root level)
 	paths := vault.GetVaultPaths(vaultPath, "")
 	machines, err := vault.ListMachines(paths)
@@ -232,8 +257,17 @@ func runMachineRm(machineName string) error {
 		return nil
 	}
 
-	// Remove machine
-	if err := vault.RemoveMachineFromVault(paths, machineID); err != nil {
+	// Remove the machine and all of its wrapped keys together
+	tx, err := vault.BeginTransaction(vaultPath, fmt.Sprintf("remove machine %s", machineID))
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := vault.RemoveMachineFromVault(paths.WithTransaction(tx), machineID); err != nil {
+		return fmt.Errorf("failed to remove machine: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to remove machine: %w", err)
 	}
 
@@ -301,11 +335,18 @@ func runMachineGrant(machineID, environment, project string) error {
 	}
 
 	// Find vault path
-	vaultPath, err := findVaultPath()
+	vaultPath, project, err := findProjectVault(project)
 	if err != nil {
 		return err
 	}
 
+	// Hold the vault lock for the duration of the command
+	unlock, err := lockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Pull latest changes in global mode BEFORE doing any work
 	if vault.IsGlobalMode(vaultPath) {
 		repoPath := vault.GetRepoPathFromVault(vaultPath)
@@ -329,6 +370,11 @@ func runMachineGrant(machineID, environment, project string) error {
 		}
 	}
 
+	// Refuse to modify vaults written by a newer nvolt
+	if err := ensureVaultWritable(vaultPath); err != nil {
+		return err
+	}
+
 	// Get vault paths
 	paths := vault.GetVaultPaths(vaultPath, project)
 
@@ -376,8 +422,17 @@ func runMachineGrant(machineID, environment, project string) error {
 
 	// Grant access to the machine
 	ui.Step(fmt.Sprintf("Granting access to %s", ui.Cyan(machineID)))
-	wasGranted, err := vault.GrantMachineAccess(paths, environment, machineID, masterKey, currentMachine.ID)
+	tx, err := vault.BeginTransaction(vaultPath, fmt.Sprintf("grant %s access to %s", machineID, environment))
 	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	wasGranted, err := vault.GrantMachineAccess(paths.WithTransaction(tx), environment, machineID, masterKey, currentMachine.ID)
+	if err != nil {
+		return fmt.Errorf("failed to grant access: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to grant access: %w", err)
 	}
 
@@ -416,30 +471,110 @@ func runMachineGrant(machineID, environment, project string) error {
 
 // findVaultPath tries to find the vault in local or global mode
 func findVaultPath() (string, error) {
+	// An explicitly selected global vault takes precedence over a local one
+	spec, _, err := selectedVaultSpec()
+	if err != nil {
+		return "", err
+	}
+	if spec != "" {
+		return getClonedGlobalVault(spec)
+	}
+
 	// Try local mode first
 	localPath, err := vault.GetLocalVaultPath()
 	if err == nil && vault.IsVaultInitialized(localPath) {
 		return localPath, nil
 	}
 
-	// Try to find global vault in ~/.nvolt/orgs
-	homePaths, err := vault.GetHomePaths()
-	if err != nil {
+	// Fall back to the only global vault in ~/.nvolt/orgs
+	vaultPath, err := selectGlobalVault()
+	if errors.Is(err, errNoGlobalVault) {
 		return "", fmt.Errorf("vault not found. Run 'nvolt init' first")
 	}
+	if err != nil {
+		return "", err
+	}
 
-	// Check if orgs directory exists
-	if !vault.FileExists(homePaths.Orgs) {
-		return "", fmt.Errorf("vault not found. Run 'nvolt init' first")
+	return vaultPath, nil
+}
+
+// findProjectVault finds the vault of a -p project, which may name its
+// vault (org/repo:project). Returns the vault path and the bare project name.
+func findProjectVault(project string) (string, string, error) {
+	vaultSpec, project := splitProjectSpec(project)
+	if vaultSpec == "" {
+		vaultPath, err := findVaultPath()
+		return vaultPath, project, err
 	}
 
-	// Scan for vaults in ~/.nvolt/orgs/org/repo
-	vaultPath, err := findGlobalVault(homePaths.Orgs)
+	vaultPath, err := getClonedGlobalVault(vaultSpec)
+	return vaultPath, project, err
+}
+
+// prepareVault finds the vault, pulls the latest changes in global mode and
+// resolves the project name (auto-detected if empty, ignored in local mode)
+// When write is true, the vault is locked and vaults written by a newer nvolt
+// are rejected. The returned function releases the lock and must be called.
+func prepareVault(project string, write bool) (string, string, func(), error) {
+	vaultPath, project, err := findProjectVault(project)
 	if err != nil {
-		return "", fmt.Errorf("vault not found. Run 'nvolt init' first")
+		return "", "", nil, err
 	}
 
-	return vaultPath, nil
+	unlock := func() {}
+	if write {
+		if unlock, err = lockVault(vaultPath); err != nil {
+			return "", "", nil, err
+		}
+	}
+
+	project, err = resolveVaultProject(vaultPath, project, write)
+	if err != nil {
+		unlock()
+		return "", "", nil, err
+	}
+
+	return vaultPath, project, unlock, nil
+}
+
+// resolveVaultProject pulls the latest changes in global mode, checks schema
+// compatibility and resolves the project name for prepareVault
+func resolveVaultProject(vaultPath, project string, write bool) (string, error) {
+	if vault.IsGlobalMode(vaultPath) {
+		repoPath := vault.GetRepoPathFromVault(vaultPath)
+		ui.Step("Pulling latest changes from repository")
+		if err := git.SafePull(repoPath); err != nil {
+			return "", fmt.Errorf("failed to pull latest changes: %w", err)
+		}
+		ui.Success("Repository up to date")
+	}
+
+	if write {
+		if err := ensureVaultWritable(vaultPath); err != nil {
+			return "", err
+		}
+	} else {
+		warnIfVaultNewer(vaultPath)
+	}
+
+	if !vault.IsGlobalMode(vaultPath) {
+		return "", nil
+	}
+
+	if project == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get current directory: %w", err)
+		}
+		detectedProject, _, err := config.GetProjectName(cwd, "")
+		if err != nil {
+			return "", fmt.Errorf("failed to detect project name. Use -p flag to specify: %w", err)
+		}
+		project = detectedProject
+		ui.PrintDetected("Project", project)
+	}
+
+	return project, nil
 }
 
 // ProjectResolvedInfo contains resolved project information for composition
@@ -447,6 +582,7 @@ type ProjectResolvedInfo struct {
 	ProjectName string // The project name used for vault paths (empty for local mode)
 	VaultPath   string // The vault path (either local .nvolt or global ~/.nvolt/orgs/org/repo)
 	DisplayName string // The display name for UI messages
+	Prefix      string // Prefix added to the project's keys (-p project:PREFIX_)
 }
 
 // resolveProjects resolves a list of project names into their vault paths
@@ -467,6 +603,24 @@ func resolveProjects(projectNames []string) ([]ProjectResolvedInfo, error) {
 		})
 	}
 
+	// Without -p, .nvolt.toml may compose projects below the current one
+	if len(projectNames) == 0 && len(manifestProjects()) > 0 {
+		projectNames = slices.Clone(manifestProjects())
+
+		if !hasLocal {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get current directory: %w", err)
+			}
+
+			detectedProject, _, err := config.GetProjectName(cwd, "")
+			if err != nil {
+				return nil, fmt.Errorf("failed to detect project name. Use -p flag to specify: %w", err)
+			}
+			projectNames = append(projectNames, detectedProject)
+		}
+	}
+
 	// If no projects specified
 	if len(projectNames) == 0 {
 		// If we have local, we're done
@@ -500,51 +654,45 @@ func resolveProjects(projectNames []string) ([]ProjectResolvedInfo, error) {
 	}
 
 	// Projects specified - add them all from global mode
-	// (on top of local if it exists)
-	vaultPath, err := findVaultPath()
-	if err != nil {
-		return nil, fmt.Errorf("failed to find global vault: %w", err)
-	}
+	// (on top of local if it exists). Projects may come from different
+	// vaults (org/repo:project); the rest use the selected global vault.
+	var defaultVaultPath string
+	for _, spec := range projectNames {
+		projectSpec, prefix := splitProjectPrefix(spec)
+		vaultSpec, projectName := splitProjectSpec(projectSpec)
+
+		var vaultPath string
+		if vaultSpec != "" {
+			vaultPath, err = getClonedGlobalVault(vaultSpec)
+		} else {
+			if defaultVaultPath == "" {
+				defaultVaultPath, err = selectGlobalVault()
+			}
+			vaultPath = defaultVaultPath
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find global vault for project '%s': %w", spec, err)
+		}
 
-	for _, projectName := range projectNames {
 		result = append(result, ProjectResolvedInfo{
 			ProjectName: projectName,
 			VaultPath:   vaultPath,
-			DisplayName: projectName,
+			DisplayName: projectSpec,
+			Prefix:      prefix,
 		})
 	}
 
 	return result, nil
 }
 
-// findGlobalVault searches for a vault in ~/.nvolt/orgs/
-// Returns the first valid repo root found in the structure: ~/.nvolt/orgs/org/repo
-// In global mode, the repo root contains machines/ directory at the top level
-func findGlobalVault(orgsDir string) (string, error) {
-	// List all org directories in ~/.nvolt/orgs
-	orgDirs, err := vault.ListDirs(orgsDir)
-	if err != nil {
-		return "", fmt.Errorf("no global vaults found")
+// splitProjectSpec splits "org/repo:project" into the vault and the project.
+// A plain project name has no vault.
+func splitProjectSpec(spec string) (string, string) {
+	i := strings.LastIndex(spec, ":")
+	if i < 0 || !strings.Contains(spec[:i], "/") {
+		return "", spec
 	}
-
-	// Scan each org directory for repos
-	for _, orgDir := range orgDirs {
-		repoDirs, err := vault.ListDirs(orgDir)
-		if err != nil {
-			continue
-		}
-
-		// Check each repo for a machines/ directory (global mode indicator)
-		for _, repoDir := range repoDirs {
-			machinesPath := filepath.Join(repoDir, vault.MachinesDir)
-			if vault.FileExists(machinesPath) {
-				// This is a valid global vault
-				return repoDir, nil
-			}
-		}
-	}
-
-	return "", fmt.Errorf("no global vaults found")
+	return spec[:i], spec[i+1:]
 }
 
 func init() {
@@ -555,7 +703,7 @@ func init() {
 
 	// Add flags to grant command
 	machineGrantCmd.Flags().StringP("env", "e", "default", "Environment name")
-	machineGrantCmd.Flags().StringP("project", "p", "", "Project name (auto-detected if not specified)")
+	machineGrantCmd.Flags().StringP("project", "p", "", "Project name or org/repo:project (auto-detected if not specified)")
 
 	rootCmd.AddCommand(machineCmd)
 }
@@ -0,0 +1,23 @@
+package cli
+
+import "testing"
+
+func TestSplitProjectSpec(t *testing.T) {
+	tests := []struct {
+		spec        string
+		wantVault   string
+		wantProject string
+	}{
+		{"api", "", "api"},
+		{"acme/infra:db", "acme/infra", "db"},
+		{"acme/app:api", "acme/app", "api"},
+		{"db:replica", "", "db:replica"},
+	}
+
+	for _, tt := range tests {
+		vaultSpec, project := splitProjectSpec(tt.spec)
+		if vaultSpec != tt.wantVault || project != tt.wantProject {
+			t.Errorf("splitProjectSpec(%q) = %q, %q; want %q, %q", tt.spec, vaultSpec, project, tt.wantVault, tt.wantProject)
+		}
+	}
+}
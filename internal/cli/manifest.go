@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/iluxav/nvolt/internal/config"
+	"github.com/iluxav/nvolt/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// applyProjectManifest loads .nvolt.toml from the current directory or its
+// parents, applies its default environment and checks the selected one. An
+// invalid manifest only fails commands that use it.
+func applyProjectManifest(cmd *cobra.Command) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	projectManifest, err = config.FindManifest(cwd)
+	if err == nil && projectManifest != nil {
+		err = validateManifestProjects()
+	}
+	if err != nil {
+		projectManifest = nil
+		if !usesManifest(cmd) {
+			ui.Warning("Ignoring %v", err)
+			return nil
+		}
+		return err
+	}
+	if projectManifest == nil {
+		return nil
+	}
+
+	flag := cmd.Flags().Lookup("env")
+	if flag == nil || flag.Value.Type() != "string" {
+		return nil
+	}
+
+	// Flags and NVOLT_ENV win over the manifest, which wins over config.toml
+	if !flag.Changed && os.Getenv("NVOLT_ENV") == "" && projectManifest.Environment != "" {
+		if err := flag.Value.Set(projectManifest.Environment); err != nil {
+			return err
+		}
+	}
+
+	return projectManifest.CheckEnvironment(flag.Value.String())
+}
+
+// usesManifest reports whether the command selects environments or projects,
+// and so depends on .nvolt.toml
+func usesManifest(cmd *cobra.Command) bool {
+	for _, name := range []string{"env", "project"} {
+		if cmd.Flags().Lookup(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// manifestProjects returns the projects composed by .nvolt.toml
+func manifestProjects() []string {
+	if projectManifest == nil {
+		return nil
+	}
+	return projectManifest.Compose
+}
+
+// schemaAppliesTo reports whether the .nvolt.toml schema covers the given -p
+// projects: the manifest's own project, or the current one if none is given
+func schemaAppliesTo(projects ...string) bool {
+	if !projectManifest.HasSchema() {
+		return false
+	}
+
+	explicit := false
+	for _, spec := range projects {
+		if spec == "" {
+			continue
+		}
+		explicit = true
+		spec, _ = splitProjectPrefix(spec)
+		if _, project := splitProjectSpec(spec); project == projectManifest.Project {
+			return true
+		}
+	}
+
+	return !explicit
+}
+
+// checkSecretSchema reports every secret that violates the .nvolt.toml schema
+// and fails if there is any. With partial, missing secrets are not reported.
+func checkSecretSchema(environment string, secrets map[string]string, partial bool) error {
+	violations := projectManifest.ValidateSecrets(environment, secrets, partial)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	for _, v := range violations {
+		ui.Error("%s: %s", v.Key, v.Message)
+	}
+
+	return fmt.Errorf("%d secret(s) do not match the schema in %s", len(violations), projectManifest.Path)
+}
+
+// checkPushedSecrets checks values about to be pushed to a -p project against
+// the schema. Values with ${...} references are checked once resolved.
+func checkPushedSecrets(project, environment string, secrets map[string]string) error {
+	if !schemaAppliesTo(project) {
+		return nil
+	}
+
+	resolved := make(map[string]string, len(secrets))
+	for k, v := range secrets {
+		if !strings.Contains(v, "${") {
+			resolved[k] = v
+		}
+	}
+
+	return checkSecretSchema(environment, resolved, true)
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iluxav/nvolt/internal/config"
+)
+
+func TestInvalidManifestOnlyFailsCommandsUsingIt(t *testing.T) {
+	t.Setenv("NVOLT_CONFIG", t.TempDir())
+	t.Setenv("NVOLT_ENV", "")
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	saved := projectManifest
+	t.Cleanup(func() { projectManifest = saved })
+
+	manifests := map[string]string{
+		"syntax":  "compose = [\n",
+		"compose": "compose = [\"db:../\"]\n",
+	}
+	for name, content := range manifests {
+		if err := os.WriteFile(filepath.Join(dir, config.ManifestFile), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Commands that don't select environments or projects only warn
+		for _, args := range [][]string{{"version"}, {"config", "list"}, {"vault", "show"}} {
+			cmd, _, err := rootCmd.Find(args)
+			if err != nil {
+				t.Fatalf("Command %v not found: %v", args, err)
+			}
+			if err := rootCmd.PersistentPreRunE(cmd, nil); err != nil {
+				t.Errorf("%s manifest: %v failed: %v", name, args, err)
+			}
+			if projectManifest != nil {
+				t.Errorf("%s manifest: %v should ignore the invalid manifest", name, args)
+			}
+		}
+
+		// Commands that load secrets fail, without printing their usage
+		cmd, _, err := rootCmd.Find([]string{"pull"})
+		if err != nil {
+			t.Fatalf("Command pull not found: %v", err)
+		}
+		silenceUsage := cmd.SilenceUsage
+		if err := rootCmd.PersistentPreRunE(cmd, nil); err == nil {
+			t.Errorf("%s manifest: pull should fail", name)
+		}
+		if !cmd.SilenceUsage {
+			t.Errorf("%s manifest: pull should not print its usage", name)
+		}
+		cmd.SilenceUsage = silenceUsage
+	}
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/iluxav/nvolt/internal/vault"
+)
+
+// autoMount is the --mount value that creates a private directory
+const autoMount = "auto"
+
+// secretFilePerm lets only the owner read a mounted secret
+const secretFilePerm = 0400
+
+// secretMount writes secrets as files for 'nvolt run --mount'
+type secretMount struct {
+	dir     string
+	created bool            // dir was created by nvolt and is removed on close
+	files   map[string]bool // keys with a file in dir
+}
+
+// newSecretMount prepares dir for secret files. With "auto", a private
+// directory is created in memory-backed storage when available.
+func newSecretMount(dir string) (*secretMount, error) {
+	m := &secretMount{dir: dir, files: make(map[string]bool)}
+
+	if dir == autoMount {
+		var err error
+		if m.dir, err = os.MkdirTemp(privateTempBase(), "nvolt-secrets-"); err != nil {
+			return nil, fmt.Errorf("failed to create secrets directory: %w", err)
+		}
+		m.created = true
+		return m, nil
+	}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create secrets directory: %w", err)
+		}
+		m.created = true
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to access secrets directory: %w", err)
+	}
+
+	// <KEY>_FILE paths must not depend on the command's working directory
+	if abs, err := filepath.Abs(dir); err == nil {
+		m.dir = abs
+	}
+	return m, nil
+}
+
+// privateTempBase prefers tmpfs locations so secrets never reach a disk
+func privateTempBase() string {
+	if runtime.GOOS == "linux" {
+		if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+			return dir
+		}
+		if info, err := os.Stat("/dev/shm"); err == nil && info.IsDir() {
+			return "/dev/shm"
+		}
+	}
+	return os.TempDir()
+}
+
+// Write writes one file per secret, removes files of secrets that no longer
+// exist and returns the <KEY>_FILE variables pointing at them. Existing files
+// that nvolt did not write are never replaced.
+func (m *secretMount) Write(secrets map[string]string) (map[string]string, error) {
+	vars := make(map[string]string, len(secrets))
+	for key, value := range secrets {
+		path := filepath.Join(m.dir, key)
+		// Files nvolt did not write would be overwritten, then deleted on close
+		if !m.files[key] {
+			if _, err := os.Lstat(path); err == nil {
+				return nil, fmt.Errorf("refusing to overwrite %s: it was not written by nvolt", path)
+			} else if !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to access %s: %w", path, err)
+			}
+		}
+		if err := vault.WriteFileAtomic(path, []byte(value), secretFilePerm); err != nil {
+			return nil, fmt.Errorf("failed to write secret file for %s: %w", key, err)
+		}
+		m.files[key] = true
+		vars[key+"_FILE"] = path
+	}
+
+	for key := range m.files {
+		if _, ok := secrets[key]; !ok {
+			removeSecretFile(filepath.Join(m.dir, key))
+			delete(m.files, key)
+		}
+	}
+	return vars, nil
+}
+
+// Close removes the secret files, and the directory if nvolt created it
+func (m *secretMount) Close() error {
+	for key := range m.files {
+		removeSecretFile(filepath.Join(m.dir, key))
+	}
+	m.files = make(map[string]bool)
+
+	if m.created {
+		if err := os.Remove(m.dir); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove secrets directory: %w", err)
+		}
+	}
+	return nil
+}
+
+// removeSecretFile overwrites and deletes a read-only secret file
+func removeSecretFile(path string) {
+	_ = os.Chmod(path, 0600)
+	_ = vault.SecureDeleteFile(path)
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecretMount(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "secrets")
+	m, err := newSecretMount(dir)
+	if err != nil {
+		t.Fatalf("newSecretMount failed: %v", err)
+	}
+
+	vars, err := m.Write(map[string]string{"API_KEY": "k1", "DB_HOST": "db"})
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if data, err := os.ReadFile(vars["API_KEY_FILE"]); err != nil || string(data) != "k1" {
+		t.Errorf("API_KEY_FILE contains %q, %v; want %q", data, err, "k1")
+	}
+
+	// Files of removed secrets disappear on the next write
+	if _, err := m.Write(map[string]string{"API_KEY": "k2"}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "DB_HOST")); !os.IsNotExist(err) {
+		t.Errorf("DB_HOST file still exists after the secret was removed")
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("secrets directory still exists after Close")
+	}
+}
+
+func TestSecretMountKeepsExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "CONFIG")
+	if err := os.WriteFile(existing, []byte("mine"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	m, err := newSecretMount(dir)
+	if err != nil {
+		t.Fatalf("newSecretMount failed: %v", err)
+	}
+	if _, err := m.Write(map[string]string{"API_KEY": "k1", "CONFIG": "secret"}); err == nil {
+		t.Error("Write should refuse to overwrite a file nvolt did not write")
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if data, err := os.ReadFile(existing); err != nil || string(data) != "mine" {
+		t.Errorf("Existing file contains %q, %v; want %q", data, err, "mine")
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("Existing directory was removed: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/iluxav/nvolt/internal/git"
+	"github.com/iluxav/nvolt/internal/validation"
+	"github.com/spf13/cobra"
+)
+
+// validateNameFlags checks the environment, project and vault names given to
+// cmd before they are used to build vault paths
+func validateNameFlags(cmd *cobra.Command) error {
+	for _, name := range []string{"env", "environment"} {
+		for _, env := range flagValues(cmd, name) {
+			if err := validation.ValidateEnvironmentName(env); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Composing commands accept a key prefix (-p project:PREFIX_)
+	composing := cmd.Flags().Lookup("on-conflict") != nil
+	for _, spec := range flagValues(cmd, "project") {
+		if err := validateProjectSpec(spec, composing); err != nil {
+			return err
+		}
+	}
+
+	if vaultFlag != "" {
+		if _, _, err := git.GetRepoPath(vaultFlag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateManifestProjects checks the compose entries of .nvolt.toml like -p
+// values of a composing command
+func validateManifestProjects() error {
+	for _, spec := range manifestProjects() {
+		if err := validateProjectSpec(spec, true); err != nil {
+			return fmt.Errorf("invalid compose entry '%s' in %s: %w", spec, projectManifest.Path, err)
+		}
+	}
+	return nil
+}
+
+// validateProjectSpec checks a project spec (project, org/repo:project and,
+// if allowPrefix, a trailing :PREFIX_)
+func validateProjectSpec(spec string, allowPrefix bool) error {
+	if allowPrefix {
+		var prefix string
+		if spec, prefix = splitProjectPrefix(spec); prefix != "" {
+			if err := validation.ValidateSecretKey(prefix); err != nil {
+				return fmt.Errorf("invalid key prefix '%s': %w", prefix, err)
+			}
+		}
+	}
+
+	vaultSpec, project := splitProjectSpec(spec)
+	if vaultSpec != "" {
+		if _, _, err := git.GetRepoPath(vaultSpec); err != nil {
+			return err
+		}
+	}
+	return validation.ValidateProjectName(project)
+}
+
+// flagValues returns the non-empty values of a string or string slice flag
+func flagValues(cmd *cobra.Command, name string) []string {
+	flag := cmd.Flags().Lookup(name)
+	if flag == nil {
+		return nil
+	}
+
+	var values []string
+	switch flag.Value.Type() {
+	case "string":
+		values = []string{flag.Value.String()}
+	case "stringSlice":
+		values, _ = cmd.Flags().GetStringSlice(name)
+	}
+
+	var nonEmpty []string
+	for _, value := range values {
+		if value != "" {
+			nonEmpty = append(nonEmpty, value)
+		}
+	}
+	return nonEmpty
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/iluxav/nvolt/internal/config"
+)
+
+func TestValidateManifestProjects(t *testing.T) {
+	saved := projectManifest
+	t.Cleanup(func() { projectManifest = saved })
+
+	valid := []string{"api", "db:DB_", "acme/secrets:api", "acme/secrets:db:DB_"}
+	projectManifest = &config.Manifest{Compose: valid, Path: config.ManifestFile}
+	if err := validateManifestProjects(); err != nil {
+		t.Errorf("validateManifestProjects(%v) failed: %v", valid, err)
+	}
+
+	for _, spec := range []string{"../../x", "db:../", "acme/../x:api", "acme/secrets:../api", "api:DB-"} {
+		projectManifest = &config.Manifest{Compose: []string{spec}, Path: config.ManifestFile}
+		if err := validateManifestProjects(); err == nil {
+			t.Errorf("validateManifestProjects should reject %q", spec)
+		}
+	}
+}
@@ -3,12 +3,9 @@ package cli
 import (
 	"fmt"
 	"os"
-	"path/filepath"
-	"sort"
 	"strings"
 
-	"github.com/iluxav/nvolt/internal/crypto"
-	"github.com/iluxav/nvolt/internal/git"
+	"github.com/iluxav/nvolt/internal/formats"
 	"github.com/iluxav/nvolt/internal/ui"
 	"github.com/iluxav/nvolt/internal/vault"
 	"github.com/spf13/cobra"
@@ -17,24 +14,73 @@ import (
 var pullCmd = &cobra.Command{
 	Use:   "pull",
 	Short: "Decrypt and pull secrets from vault",
-	Long: `Decrypt secrets from the vault and output them in .env format.
+	Long: `Decrypt secrets from the vault and print them to stdout. Progress
+messages go to stderr, so the output can be piped or redirected.
+
+Formats:
+  env           .env file (default)
+  json          JSON object
+  yaml          YAML mapping
+  shell-export  export KEY='value' lines for eval or source
+  docker-env    docker --env-file (single-line values only)
+  k8s-secret    Kubernetes Secret manifest (see --name and --namespace)
+  systemd       systemd EnvironmentFile=
+  github-env    Lines for $GITHUB_ENV in GitHub Actions
+  tfvars        Terraform variables
 
 Examples:
   nvolt pull
   nvolt pull -e production
   nvolt pull -e staging -p myproject
   nvolt pull -p db-connections -p file-storage  # Compose multiple projects
-  nvolt pull --write  # Write to .env file`,
+  nvolt pull --write  # Write to .env file
+  nvolt pull -e production --format k8s-secret --name api | kubectl apply -f -
+  nvolt pull -e ci --format github-env >> "$GITHUB_ENV"
+  nvolt pull -e production --format systemd -o /etc/myapp/env
+
+Secret values may reference other secrets with ${KEY}, or secrets of another
+project with ${project:env:KEY}. References are resolved at pull time; use
+$${ for a literal "${" or --no-interpolate to print raw values.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		environment, _ := cmd.Flags().GetString("env")
 		projects, _ := cmd.Flags().GetStringSlice("project")
+		format, _ := cmd.Flags().GetString("format")
+		outputFile, _ := cmd.Flags().GetString("output")
 		write, _ := cmd.Flags().GetBool("write")
+		noInterpolate, _ := cmd.Flags().GetBool("no-interpolate")
+		name, _ := cmd.Flags().GetString("name")
+		namespace, _ := cmd.Flags().GetString("namespace")
+		compose, err := getComposeOptions(cmd)
+		if err != nil {
+			return err
+		}
+		selection, err := getKeySelection(cmd)
+		if err != nil {
+			return err
+		}
 
-		return runPull(environment, projects, write)
+		if write {
+			if outputFile != "" {
+				return fmt.Errorf("--write and --output cannot be used together")
+			}
+			// Write to .env or .env.<environment>
+			outputFile = ".env"
+			if environment != "default" {
+				outputFile = fmt.Sprintf(".env.%s", environment)
+			}
+		}
+
+		opts := formats.ExportOptions{Name: name, Namespace: namespace}
+		return runPull(environment, projects, format, outputFile, noInterpolate, compose, selection, opts)
 	},
 }
 
-func runPull(environment string, projects []string, write bool) error {
+func runPull(environment string, projects []string, format, outputFile string, noInterpolate bool, compose composeOptions, selection keySelection, opts formats.ExportOptions) error {
+	// Keep stdout for the secrets
+	if outputFile == "" {
+		ui.SetOutput(os.Stderr)
+	}
+
 	// Ensure machine is initialized
 	if err := EnsureMachineInitialized(); err != nil {
 		return err
@@ -42,138 +88,50 @@ func runPull(environment string, projects []string, write bool) error {
 
 	ui.Step("Pulling secrets from vault")
 
-	// Resolve projects to load
-	projectsToLoad, err := resolveProjects(projects)
+	projectsToLoad, err := resolveCommandProjects(projects, true)
 	if err != nil {
 		return err
 	}
 
-	// Show which projects we're loading
-	if len(projectsToLoad) > 1 {
-		projectNames := make([]string, len(projectsToLoad))
-		for i, p := range projectsToLoad {
-			projectNames[i] = p.DisplayName
-		}
-		ui.Info(fmt.Sprintf("Loading projects: %s", ui.Cyan(strings.Join(projectNames, ", "))))
-	} else if len(projectsToLoad) == 1 {
-		ui.PrintDetected("Project", projectsToLoad[0].DisplayName)
-	}
-
-	// Pull git changes if in global mode (only once for the vault)
-	if len(projectsToLoad) > 0 && vault.IsGlobalMode(projectsToLoad[0].VaultPath) {
-		repoPath := vault.GetRepoPathFromVault(projectsToLoad[0].VaultPath)
-		ui.Step("Pulling latest changes from repository")
-		if err := git.SafePull(repoPath); err != nil {
-			return fmt.Errorf("failed to pull from repository: %w", err)
-		}
-		ui.Success("Repository up to date")
-	}
-
 	// Load and merge secrets from all projects
-	allSecrets := make(map[string]string)
-	for _, projectInfo := range projectsToLoad {
-		ui.Info(fmt.Sprintf("  Loading secrets from project: %s", ui.Cyan(projectInfo.DisplayName)))
-		paths := vault.GetVaultPaths(projectInfo.VaultPath, projectInfo.ProjectName)
-
-		// Unwrap master key for this project
-		masterKey, err := vault.UnwrapMasterKey(paths, environment)
-		if err != nil {
-			return fmt.Errorf("failed to unwrap master key for project '%s': %w\nMake sure you have pushed secrets first", projectInfo.DisplayName, err)
-		}
-
-		// Get list of secret files for this environment
-		secretsDir := paths.GetSecretsPath(environment)
-		secretFiles, err := vault.ListFiles(secretsDir)
-		if err != nil {
-			crypto.ZeroBytes(masterKey)
-			return fmt.Errorf("failed to list secrets for project '%s': %w", projectInfo.DisplayName, err)
-		}
-
-		if len(secretFiles) == 0 {
-			crypto.ZeroBytes(masterKey)
-			ui.Warning(fmt.Sprintf("No secrets found for project '%s' in environment '%s'", projectInfo.DisplayName, environment))
-			continue
-		}
-
-		// Decrypt all secrets from this project
-		for _, secretFile := range secretFiles {
-			// Extract key name from filename (remove .enc.json)
-			filename := filepath.Base(secretFile)
-			if !filepath.IsAbs(secretFile) {
-				secretFile = filepath.Join(secretsDir, filename)
-			}
-
-			// Get key name
-			key := filename
-			if len(key) > 9 && key[len(key)-9:] == ".enc.json" {
-				key = key[:len(key)-9]
-			}
-
-			// Load and decrypt secret
-			encrypted, err := vault.LoadEncryptedSecret(paths, environment, key)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to load secret %s from project %s: %v\n", key, projectInfo.DisplayName, err)
-				continue
-			}
-
-			value, err := vault.DecryptSecret(masterKey, encrypted)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to decrypt secret %s from project %s: %v\n", key, projectInfo.DisplayName, err)
-				continue
-			}
-
-			// Merge into allSecrets (last one wins on conflicts)
-			allSecrets[key] = value
-		}
-
-		// Clear master key from memory
-		crypto.ZeroBytes(masterKey)
+	allSecrets, err := loadProjectSecrets(projectsToLoad, environment, noInterpolate, compose, selection)
+	if err != nil {
+		return err
 	}
-
-	if len(allSecrets) == 0 {
-		return fmt.Errorf("no secrets could be decrypted from any project")
+	if allSecrets, err = selection.apply(allSecrets); err != nil {
+		return err
 	}
 
 	ui.Success(fmt.Sprintf("Decrypted %d secrets from environment '%s'", len(allSecrets), ui.Cyan(environment)))
 
-	// Format output
-	output := vault.FormatEnvOutput(allSecrets)
-
-	if write {
-		// Write to .env file
-		envFile := ".env"
-		if environment != "default" {
-			envFile = fmt.Sprintf(".env.%s", environment)
-		}
-
-		err := os.WriteFile(envFile, []byte(output), 0644)
-		if err != nil {
-			return fmt.Errorf("failed to write .env file: %w", err)
-		}
-
-		ui.Success(fmt.Sprintf("Written to %s", ui.Cyan(envFile)))
-	} else {
-		// Print to stdout
-		ui.Section("Secrets:")
+	output, err := formats.Export(format, allSecrets, opts)
+	if err != nil {
+		return err
+	}
 
-		// Sort keys for consistent output
-		keys := make([]string, 0, len(allSecrets))
-		for k := range allSecrets {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
+	if outputFile == "" {
+		fmt.Print(output)
+		return nil
+	}
 
-		for _, key := range keys {
-			fmt.Printf("%s=%s\n", key, allSecrets[key])
-		}
+	if err := vault.WriteFileAtomic(outputFile, []byte(output), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputFile, err)
 	}
+	ui.Success(fmt.Sprintf("Written to %s", ui.Cyan(outputFile)))
 
 	return nil
 }
 
 func init() {
 	pullCmd.Flags().StringP("env", "e", "default", "Environment name")
-	pullCmd.Flags().StringSliceP("project", "p", []string{}, "Project name(s) - can be specified multiple times for composition")
-	pullCmd.Flags().BoolP("write", "w", false, "Write output to .env file")
+	pullCmd.Flags().StringSliceP("project", "p", []string{}, "Project name(s) or org/repo:project - can be specified multiple times for composition")
+	pullCmd.Flags().String("format", formats.Env, "Output format: "+strings.Join(formats.ExportFormats, ", "))
+	pullCmd.Flags().StringP("output", "o", "", "Write to this file (mode 0600) instead of stdout")
+	pullCmd.Flags().BoolP("write", "w", false, "Write output to .env (or .env.<environment>)")
+	pullCmd.Flags().String("name", formats.DefaultSecretName, "Name of the Kubernetes Secret for --format k8s-secret")
+	pullCmd.Flags().String("namespace", "", "Namespace of the Kubernetes Secret for --format k8s-secret")
+	pullCmd.Flags().Bool("no-interpolate", false, "Do not resolve ${KEY} and ${project:env:KEY} references")
+	addComposeFlags(pullCmd)
+	addSelectionFlags(pullCmd)
 	rootCmd.AddCommand(pullCmd)
 }
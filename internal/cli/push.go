@@ -50,11 +50,19 @@ func runPush(envFile, environment, project string, keyValues []string, dryRun bo
 	}
 
 	// Find vault path
-	vaultPath, err := findVaultPath()
+	explicitProject := project
+	vaultPath, project, err := findProjectVault(project)
 	if err != nil {
 		return err
 	}
 
+	// Hold the vault lock for the duration of the command
+	unlock, err := lockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Determine project name and get vault paths
 	if vault.IsGlobalMode(vaultPath) {
 		repoPath := vault.GetRepoPathFromVault(vaultPath)
@@ -81,8 +89,10 @@ func runPush(envFile, environment, project string, keyValues []string, dryRun bo
 		}
 	}
 
-	// Get vault paths with unified logic (projectName is ignored in local mode)
-	paths := vault.GetVaultPaths(vaultPath, project)
+	// Refuse to modify vaults written by a newer nvolt
+	if err := ensureVaultWritable(vaultPath); err != nil {
+		return err
+	}
 
 	// Collect secrets from file and/or command line
 	secrets := make(map[string]string)
@@ -118,6 +128,20 @@ func runPush(envFile, environment, project string, keyValues []string, dryRun bo
 		return fmt.Errorf("no secrets to push. Use -f to specify a file or -k to add key=value pairs")
 	}
 
+	// Reject values that violate the .nvolt.toml schema
+	if err := checkPushedSecrets(explicitProject, environment, secrets); err != nil {
+		return err
+	}
+
+	return storeSecrets(vaultPath, project, environment, secrets, dryRun)
+}
+
+// storeSecrets encrypts secrets into an environment, wraps the master key for
+// machines with access and commits the change in global mode
+func storeSecrets(vaultPath, project, environment string, secrets map[string]string, dryRun bool) error {
+	// Get vault paths with unified logic (projectName is ignored in local mode)
+	paths := vault.GetVaultPaths(vaultPath, project)
+
 	// Check if master key exists or generate new one
 	masterKey, isNew, err := getOrCreateMasterKey(paths, environment)
 	if err != nil {
@@ -138,10 +162,18 @@ func runPush(envFile, environment, project string, keyValues []string, dryRun bo
 		return fmt.Errorf("failed to load machine info: %w", err)
 	}
 
+	// Stage all writes so the push is applied completely or not at all
+	tx, err := vault.BeginTransaction(vaultPath, fmt.Sprintf("push %s", environment))
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	txPaths := paths.WithTransaction(tx)
+
 	// Wrap master key for machines that already have access (and current machine)
 	// Use 'nvolt machine grant <machine-id>' to grant access to new machines
 	ui.Step("Wrapping master key for machines with access")
-	if err := vault.WrapMasterKeyForExistingMachines(paths, environment, masterKey, machineInfo.ID); err != nil {
+	if err := vault.WrapMasterKeyForExistingMachines(txPaths, environment, masterKey, machineInfo.ID); err != nil {
 		return fmt.Errorf("failed to wrap master key: %w", err)
 	}
 	ui.Success("Master key wrapped for machines with access")
@@ -155,7 +187,7 @@ func runPush(envFile, environment, project string, keyValues []string, dryRun bo
 		}
 
 		if !dryRun {
-			if err := vault.SaveEncryptedSecret(paths, environment, key, encrypted); err != nil {
+			if err := vault.SaveEncryptedSecret(txPaths, environment, key, encrypted); err != nil {
 				return fmt.Errorf("failed to save secret %s: %w", key, err)
 			}
 		} else {
@@ -163,6 +195,13 @@ func runPush(envFile, environment, project string, keyValues []string, dryRun bo
 		}
 	}
 
+	// Dry runs are rolled back by the deferred Rollback
+	if !dryRun {
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to apply changes: %w", err)
+		}
+	}
+
 	ui.Success(fmt.Sprintf("Successfully pushed %d secrets", len(secrets)))
 	ui.PrintKeyValue("  Environment", ui.Cyan(environment))
 	ui.PrintKeyValue("  Vault", ui.Gray(vaultPath))
@@ -223,7 +262,7 @@ func unwrapMasterKey(paths *vault.Paths, environment string) ([]byte, error) {
 
 	// Load wrapped key
 	wrappedKeyPath := paths.GetWrappedKeyPath(environment, machineID)
-	data, err := vault.ReadFile(wrappedKeyPath)
+	data, err := paths.Storage().ReadFile(wrappedKeyPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read wrapped key: %w (machine may not have access to '%s' environment)", err, environment)
 	}
@@ -257,7 +296,7 @@ func unwrapMasterKey(paths *vault.Paths, environment string) ([]byte, error) {
 func init() {
 	pushCmd.Flags().StringP("file", "f", "", "Environment file to encrypt")
 	pushCmd.Flags().StringP("env", "e", "default", "Environment name")
-	pushCmd.Flags().StringP("project", "p", "", "Project name (auto-detected if not specified)")
+	pushCmd.Flags().StringP("project", "p", "", "Project name or org/repo:project (auto-detected if not specified)")
 	pushCmd.Flags().StringSliceP("key", "k", []string{}, "Key=value pairs (can be specified multiple times)")
 	pushCmd.Flags().Bool("dry-run", false, "Show what would be done without making any changes")
 	rootCmd.AddCommand(pushCmd)
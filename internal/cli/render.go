@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/iluxav/nvolt/internal/render"
+	"github.com/iluxav/nvolt/internal/ui"
+	"github.com/iluxav/nvolt/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render <TEMPLATE>",
+	Short: "Render a config file template with decrypted secrets",
+	Long: `Render a Go text/template with the secrets of an environment, for services
+that read configuration files instead of environment variables. The output is
+written atomically with mode 0600, or printed to stdout without --output.
+
+Template data:
+  {{ .Environment }}               Environment name
+  {{ .Secrets.KEY }}               Secret value (fails if KEY does not exist)
+  {{ index .Secrets "KEY" }}       Secret value, or "" if KEY does not exist
+
+Functions:
+  secret "KEY"          Secret value (fails if KEY does not exist)
+  hasSecret "KEY"       Whether KEY exists
+  default "x" VALUE     VALUE, or "x" if VALUE is empty
+  required "msg" VALUE  VALUE, or fail with msg if VALUE is empty
+  base64, base64decode  Encode or decode base64
+  json                  Quote as a JSON string
+  indent N, upper, lower, trim, keys
+
+Examples:
+  nvolt render -e production nginx.conf.tmpl -o /etc/nginx/conf.d/app.conf
+  nvolt render -e staging application.yml.tmpl -o application.yml
+  nvolt render -e production pgbouncer.ini.tmpl --check`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		environment, _ := cmd.Flags().GetString("env")
+		projects, _ := cmd.Flags().GetStringSlice("project")
+		outputFile, _ := cmd.Flags().GetString("output")
+		check, _ := cmd.Flags().GetBool("check")
+		noInterpolate, _ := cmd.Flags().GetBool("no-interpolate")
+		compose, err := getComposeOptions(cmd)
+		if err != nil {
+			return err
+		}
+		selection, err := getKeySelection(cmd)
+		if err != nil {
+			return err
+		}
+
+		return runRender(args[0], environment, projects, outputFile, check, noInterpolate, compose, selection)
+	},
+}
+
+func runRender(templateFile, environment string, projects []string, outputFile string, check, noInterpolate bool, compose composeOptions, selection keySelection) error {
+	// Keep stdout for the rendered output
+	if outputFile == "" && !check {
+		ui.SetOutput(os.Stderr)
+	}
+
+	text, err := os.ReadFile(templateFile)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+
+	// Ensure machine is initialized
+	if err := EnsureMachineInitialized(); err != nil {
+		return err
+	}
+
+	ui.Step("Rendering %s with secrets of environment '%s'", ui.Cyan(templateFile), ui.Cyan(environment))
+
+	projectsToLoad, err := resolveCommandProjects(projects, true)
+	if err != nil {
+		return err
+	}
+
+	secrets, err := loadProjectSecrets(projectsToLoad, environment, noInterpolate, compose, selection)
+	if err != nil {
+		return err
+	}
+	if secrets, err = selection.apply(secrets); err != nil {
+		return err
+	}
+
+	output, err := render.Render(filepath.Base(templateFile), string(text), render.Data{
+		Environment: environment,
+		Secrets:     secrets,
+	})
+	if err != nil {
+		return err
+	}
+
+	if check {
+		ui.Success("Template renders with %d secrets from environment '%s'", len(secrets), ui.Cyan(environment))
+		return nil
+	}
+
+	if outputFile == "" {
+		fmt.Print(output)
+		return nil
+	}
+
+	if err := vault.WriteFileAtomic(outputFile, []byte(output), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputFile, err)
+	}
+	ui.Success("Written to %s", ui.Cyan(outputFile))
+	return nil
+}
+
+func init() {
+	renderCmd.Flags().StringP("env", "e", "default", "Environment name")
+	renderCmd.Flags().StringSliceP("project", "p", []string{}, "Project name(s) or org/repo:project - can be specified multiple times for composition")
+	renderCmd.Flags().StringP("output", "o", "", "Write to this file (mode 0600) instead of stdout")
+	renderCmd.Flags().Bool("check", false, "Render the template without writing the output")
+	renderCmd.Flags().Bool("no-interpolate", false, "Do not resolve ${KEY} and ${project:env:KEY} references")
+	addComposeFlags(renderCmd)
+	addSelectionFlags(renderCmd)
+	rootCmd.AddCommand(renderCmd)
+}
@@ -1,16 +1,27 @@
 package cli
 
 import (
+	"time"
+
+	"github.com/iluxav/nvolt/internal/config"
 	"github.com/iluxav/nvolt/internal/ui"
 	"github.com/spf13/cobra"
 )
 
 var (
-	version  = "dev"
-	verbose  bool
-	debug    bool
-	quiet    bool
-	noColor  bool
+	version   = "dev"
+	verbose   bool
+	debug     bool
+	quiet     bool
+	noColor   bool
+	lockWait  time.Duration
+	vaultFlag string
+
+	// userConfig is ~/.nvolt/config.toml, loaded before every command
+	userConfig = &config.UserConfig{}
+
+	// projectManifest is the .nvolt.toml of the current project, or nil
+	projectManifest *config.Manifest
 )
 
 var rootCmd = &cobra.Command{
@@ -23,7 +34,7 @@ All data lives in Git repositories, with encryption/decryption happening
 locally using per-machine keypairs. Access control is cryptographically
 enforced through wrapped key files.`,
 	Version: version,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		// Disable colors if requested
 		if noColor {
 			ui.SetColorsEnabled(false)
@@ -39,6 +50,19 @@ enforced through wrapped key files.`,
 		} else {
 			ui.SetLevel(ui.LevelInfo)
 		}
+
+		// Apply user defaults below flags and environment variables
+		applyUserConfig(cmd)
+
+		// Apply .nvolt.toml of the current project
+		if err := applyProjectManifest(cmd); err != nil {
+			// A broken manifest is not a usage error
+			cmd.SilenceUsage = true
+			return err
+		}
+
+		// Reject names that could escape their directory in the vault
+		return validateNameFlags(cmd)
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		// Show logo when running without subcommand
@@ -58,6 +82,8 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "Enable debug output (includes verbose)")
 	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Suppress all output except errors")
 	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output")
+	rootCmd.PersistentFlags().DurationVar(&lockWait, "wait", 0, "Wait up to this long for a locked vault (e.g. 30s)")
+	rootCmd.PersistentFlags().StringVar(&vaultFlag, "vault", "", "Global vault to use (org/repo); overrides NVOLT_VAULT")
 
 	// Custom version template with logo
 	rootCmd.SetVersionTemplate(`{{with .Name}}{{printf "%s " .}}{{end}}{{printf "%s" .Version}}
@@ -14,7 +14,7 @@ func TestRootCommand(t *testing.T) {
 }
 
 func TestCommandsRegistered(t *testing.T) {
-	commands := []string{"init", "push", "pull", "run", "machine", "vault", "sync"}
+	commands := []string{"init", "push", "pull", "run", "machine", "vault", "sync", "env", "backup", "config", "validate", "generate", "import", "render"}
 
 	for _, cmdName := range commands {
 		cmd, _, err := rootCmd.Find([]string{cmdName})
@@ -1,15 +1,18 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
-	"github.com/iluxav/nvolt/internal/crypto"
+	"github.com/iluxav/nvolt/internal/process"
+	"github.com/iluxav/nvolt/internal/redact"
 	"github.com/iluxav/nvolt/internal/ui"
-	"github.com/iluxav/nvolt/internal/vault"
 	"github.com/spf13/cobra"
 )
 
@@ -22,12 +25,67 @@ Examples:
   nvolt run npm start
   nvolt run -e production ./app
   nvolt run -p db-connections -p file-storage node index.js  # Compose multiple projects
-  nvolt run -c "go test ./..."`,
+  nvolt run -c "go test ./..."
+  nvolt run --exec -e production ./server  # Replace nvolt, e.g. as PID 1 in a container
+  nvolt run --watch npm run dev            # Restart when secrets change
+  nvolt run --mount auto ./server          # Secrets as files, $API_KEY_FILE
+  nvolt run --redact -- go test ./...      # Mask secret values in the output
+  nvolt run --isolated --keep PATH,HOME,TERM -- ./app  # Only pass these variables and the secrets
+
+Signals sent to nvolt (SIGTERM from Docker or systemd, SIGHUP, SIGUSR1, ...)
+are forwarded to the command, and nvolt exits with the command's exit status,
+or is killed by the same signal. Signals generated by the terminal (^C, ^\)
+already reach the command directly. As PID 1, nvolt also reaps orphaned
+processes; with --exec the command replaces nvolt and takes over that role.
+
+With --watch, nvolt checks the vault every --watch-interval (pulling global
+vaults first) and restarts the command when a secret it receives changes: it
+sends --restart-signal, waits up to --grace-period and then kills it.
+
+With --mount DIR, secrets are not passed as environment variables, which
+other processes of the same user can read from /proc. Each secret is written
+to DIR/<KEY> readable only by its owner, <KEY>_FILE points at that file, and
+the files are removed when the command exits. Existing files in DIR are never
+replaced. "--mount auto" creates a private directory, in memory (tmpfs) where
+available.
+
+With --redact, the command's output passes through nvolt, which replaces
+secret values (and their base64, URL-encoded and JSON-escaped forms) with
+***KEY***. Values shorter than 4 characters are not redacted.
+
+The command inherits nvolt's environment, and secrets replace inherited
+variables of the same name (nvolt warns when their values differ). With
+--isolated, only the variables matching --keep (names or patterns like LC_*)
+are inherited.
+
+When .nvolt.toml declares a secret schema, the command is not started if a
+required secret is missing or a value does not match its type.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		environment, _ := cmd.Flags().GetString("env")
 		projects, _ := cmd.Flags().GetStringSlice("project")
 		command, _ := cmd.Flags().GetString("command")
 
+		var opts runOptions
+		opts.noInterpolate, _ = cmd.Flags().GetBool("no-interpolate")
+		opts.noValidate, _ = cmd.Flags().GetBool("no-validate")
+		opts.exec, _ = cmd.Flags().GetBool("exec")
+		opts.mount, _ = cmd.Flags().GetString("mount")
+		opts.redact, _ = cmd.Flags().GetBool("redact")
+		opts.isolated, _ = cmd.Flags().GetBool("isolated")
+		opts.keep, _ = cmd.Flags().GetStringSlice("keep")
+		opts.watch, _ = cmd.Flags().GetBool("watch")
+		opts.watchInterval, _ = cmd.Flags().GetDuration("watch-interval")
+		opts.gracePeriod, _ = cmd.Flags().GetDuration("grace-period")
+		restartSignal, _ := cmd.Flags().GetString("restart-signal")
+
+		var err error
+		if opts.compose, err = getComposeOptions(cmd); err != nil {
+			return err
+		}
+		if opts.selection, err = getKeySelection(cmd); err != nil {
+			return err
+		}
+
 		var execArgs []string
 		if command != "" {
 			// Use shell to execute string command
@@ -39,122 +97,232 @@ Examples:
 			return fmt.Errorf("no command specified")
 		}
 
-		return runWithSecrets(environment, projects, execArgs)
+		if len(opts.keep) > 0 && !opts.isolated {
+			return fmt.Errorf("--keep requires --isolated")
+		}
+		if opts.exec && opts.redact {
+			return fmt.Errorf("--redact cannot be used with --exec: nvolt must stay running to filter the output")
+		}
+		if opts.exec && opts.mount != "" {
+			return fmt.Errorf("--mount cannot be used with --exec: nvolt must stay running to remove the files")
+		}
+		if opts.watch {
+			if opts.exec {
+				return fmt.Errorf("--watch cannot be used with --exec")
+			}
+			if opts.watchInterval <= 0 {
+				return fmt.Errorf("--watch-interval must be positive")
+			}
+			if opts.restartSignal, err = process.ParseSignal(restartSignal); err != nil {
+				return err
+			}
+		}
+
+		err = runWithSecrets(environment, projects, execArgs, opts)
+
+		// The command already reported its own failure; main exits with its status
+		var exitErr *process.ExitError
+		if errors.As(err, &exitErr) {
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+		}
+		return err
 	},
 }
 
-func runWithSecrets(environment string, projects []string, cmdArgs []string) error {
+// runOptions are the flags of 'nvolt run'
+type runOptions struct {
+	noInterpolate bool
+	noValidate    bool
+	compose       composeOptions
+	selection     keySelection
+	exec          bool
+	mount         string
+	redact        bool
+	isolated      bool
+	keep          []string
+
+	watch         bool
+	watchInterval time.Duration
+	restartSignal os.Signal
+	gracePeriod   time.Duration
+}
+
+func runWithSecrets(environment string, projects []string, cmdArgs []string, opts runOptions) error {
 	// Ensure machine is initialized
 	if err := EnsureMachineInitialized(); err != nil {
 		return err
 	}
 
-	// Resolve projects to load
-	projectsToLoad, err := resolveProjects(projects)
+	// Global vaults are pulled by --watch only
+	projectsToLoad, err := resolveCommandProjects(projects, false)
 	if err != nil {
 		return err
 	}
 
-	// Show which projects we're loading
-	if len(projectsToLoad) > 1 {
-		projectNames := make([]string, len(projectsToLoad))
-		for i, p := range projectsToLoad {
-			projectNames[i] = p.DisplayName
-		}
-		ui.Info(fmt.Sprintf("Loading projects: %s", ui.Cyan(strings.Join(projectNames, ", "))))
-	}
-
 	// Load and merge secrets from all projects
-	allSecrets := make(map[string]string)
-	for _, projectInfo := range projectsToLoad {
-		paths := vault.GetVaultPaths(projectInfo.VaultPath, projectInfo.ProjectName)
-
-		// Unwrap master key for this project
-		masterKey, err := vault.UnwrapMasterKey(paths, environment)
+	loadSecrets := func() (map[string]string, error) {
+		allSecrets, err := loadProjectSecrets(projectsToLoad, environment, opts.noInterpolate, opts.compose, opts.selection)
 		if err != nil {
-			return fmt.Errorf("failed to unwrap master key for project '%s': %w", projectInfo.DisplayName, err)
+			return nil, err
 		}
 
-		// Get list of secret files
-		secretsDir := paths.GetSecretsPath(environment)
-		secretFiles, err := vault.ListFiles(secretsDir)
-		if err != nil {
-			crypto.ZeroBytes(masterKey)
-			return fmt.Errorf("failed to list secrets for project '%s': %w", projectInfo.DisplayName, err)
+		// Refuse to start with secrets that violate the .nvolt.toml schema
+		if !opts.noValidate && schemaAppliesTo(projects...) {
+			if err := checkSecretSchema(environment, allSecrets, false); err != nil {
+				return nil, err
+			}
 		}
 
-		if len(secretFiles) == 0 {
-			crypto.ZeroBytes(masterKey)
-			ui.Warning(fmt.Sprintf("No secrets found for project '%s' in environment '%s'", projectInfo.DisplayName, environment))
-			continue
+		// The schema describes the composed secrets, before keys are renamed
+		return opts.selection.apply(allSecrets)
+	}
+
+	allSecrets, err := loadSecrets()
+	if err != nil {
+		return err
+	}
+
+	ui.Success(fmt.Sprintf("Loaded %d secrets from environment '%s'", len(allSecrets), ui.Cyan(environment)))
+	ui.Info("Running: %s\n", ui.Gray(strings.Join(cmdArgs, " ")))
+
+	// Secrets take precedence over inherited variables
+	childEnv := func(secrets map[string]string) []string {
+		env, shadowed := buildChildEnv(os.Environ(), secrets, opts.isolated, opts.keep)
+		if len(shadowed) > 0 {
+			ui.Warning("Secrets override variables of your environment: %s", strings.Join(shadowed, ", "))
 		}
+		return env
+	}
 
-		// Decrypt all secrets from this project
-		for _, secretFile := range secretFiles {
-			filename := filepath.Base(secretFile)
+	// Replace nvolt with the command
+	if opts.exec {
+		if err := process.Exec(cmdArgs, childEnv(allSecrets)); err != nil {
+			return fmt.Errorf("failed to execute command: %w", err)
+		}
+	}
 
-			// Get key name
-			key := filename
-			if len(key) > 9 && key[len(key)-9:] == ".enc.json" {
-				key = key[:len(key)-9]
+	// Deliver secrets as files instead of environment variables
+	var mount *secretMount
+	if opts.mount != "" {
+		if mount, err = newSecretMount(opts.mount); err != nil {
+			return err
+		}
+		defer func() {
+			if err := mount.Close(); err != nil {
+				ui.Warning("%v", err)
 			}
+		}()
+	}
 
-			// Load and decrypt secret
-			encrypted, err := vault.LoadEncryptedSecret(paths, environment, key)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to load secret %s from project %s: %v\n", key, projectInfo.DisplayName, err)
-				continue
-			}
+	// Mask secrets in the command's output
+	var stdout, stderr *redact.Writer
+	flushOutput := func() {
+		if stdout != nil {
+			_ = stdout.Flush()
+			_ = stderr.Flush()
+		}
+	}
+	defer flushOutput()
+	if opts.redact {
+		warnUnredactedSecrets(allSecrets)
+	}
 
-			value, err := vault.DecryptSecret(masterKey, encrypted)
+	start := func(secrets map[string]string) (*process.Child, error) {
+		var out, errOut io.Writer = os.Stdout, os.Stderr
+		if opts.redact {
+			// Output of a previous (watched) command is complete once it exited
+			flushOutput()
+			r := redact.New(secrets)
+			stdout, stderr = redact.NewWriter(os.Stdout, r), redact.NewWriter(os.Stderr, r)
+			out, errOut = stdout, stderr
+		}
+
+		env := secrets
+		if mount != nil {
+			vars, err := mount.Write(secrets)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Warning: Failed to decrypt secret %s from project %s: %v\n", key, projectInfo.DisplayName, err)
-				continue
+				return nil, err
 			}
+			env = vars
+			ui.Verbose("  Mounted %d secrets in %s", len(secrets), mount.dir)
+		}
 
-			// Merge into allSecrets (last one wins on conflicts)
-			allSecrets[key] = value
+		// Execute command, forwarding signals and propagating its exit status
+		cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
+		cmd.Env = childEnv(env)
+		if opts.redact {
+			cmd.Env = append(cmd.Env, colorHints(cmd.Env)...)
 		}
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = out
+		cmd.Stderr = errOut
 
-		// Clear master key from memory
-		crypto.ZeroBytes(masterKey)
+		child, err := process.Start(cmd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute command: %w", err)
+		}
+		return child, nil
 	}
 
-	if len(allSecrets) == 0 {
-		return fmt.Errorf("no secrets could be decrypted from any project")
+	if opts.watch {
+		return watchAndRestart(projectsToLoad, allSecrets, loadSecrets, start, opts)
 	}
 
-	ui.Success(fmt.Sprintf("Loaded %d secrets from environment '%s'", len(allSecrets), ui.Cyan(environment)))
-	ui.Info(fmt.Sprintf("Running: %s\n", ui.Gray(strings.Join(cmdArgs, " "))))
+	child, err := start(allSecrets)
+	if err != nil {
+		return err
+	}
+	return child.Wait()
+}
 
-	// Prepare environment
-	env := os.Environ()
-	for key, value := range allSecrets {
-		env = append(env, fmt.Sprintf("%s=%s", key, value))
+// warnUnredactedSecrets reports secrets too short to be redacted
+func warnUnredactedSecrets(secrets map[string]string) {
+	var short []string
+	for key, value := range secrets {
+		if value != "" && len(value) < redact.MinLength {
+			short = append(short, key)
+		}
+	}
+	if len(short) > 0 {
+		slices.Sort(short)
+		ui.Warning("Not redacting %s: values shorter than %d characters", strings.Join(short, ", "), redact.MinLength)
 	}
+}
 
-	// Execute command
-	cmd := exec.Command(cmdArgs[0], cmdArgs[1:]...)
-	cmd.Env = env
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+// colorHints keeps colored output when the command's output is redacted:
+// it then writes to a pipe, but nvolt passes the output on to a terminal
+func colorHints(env []string) []string {
+	info, err := os.Stdout.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
 
-	err = cmd.Run()
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			// Command exited with non-zero status
-			os.Exit(exitErr.ExitCode())
+	var hints []string
+	for _, name := range []string{"FORCE_COLOR", "CLICOLOR_FORCE"} {
+		if !envHas(env, name) {
+			hints = append(hints, name+"=1")
 		}
-		return fmt.Errorf("failed to execute command: %w", err)
 	}
-
-	return nil
+	return hints
 }
 
 func init() {
 	runCmd.Flags().StringP("env", "e", "default", "Environment name")
-	runCmd.Flags().StringSliceP("project", "p", []string{}, "Project name(s) - can be specified multiple times for composition")
+	runCmd.Flags().StringSliceP("project", "p", []string{}, "Project name(s) or org/repo:project - can be specified multiple times for composition")
 	runCmd.Flags().StringP("command", "c", "", "Command to execute")
+	runCmd.Flags().Bool("no-interpolate", false, "Do not resolve ${KEY} and ${project:env:KEY} references")
+	addComposeFlags(runCmd)
+	addSelectionFlags(runCmd)
+	runCmd.Flags().Bool("exec", false, "Replace nvolt with the command instead of supervising it")
+	runCmd.Flags().String("mount", "", "Write secrets as files to this directory (or \"auto\") and pass <KEY>_FILE variables instead")
+	runCmd.Flags().Bool("isolated", false, "Do not pass nvolt's environment to the command, except --keep variables")
+	runCmd.Flags().StringSlice("keep", []string{}, "Variables passed to the command with --isolated (e.g. PATH,HOME,TERM; patterns like LC_* allowed)")
+	runCmd.Flags().Bool("redact", false, "Mask secret values in the command's output")
+	runCmd.Flags().Bool("watch", false, "Restart the command when its secrets change in the vault")
+	runCmd.Flags().Duration("watch-interval", 10*time.Second, "How often --watch checks the vault for changes")
+	runCmd.Flags().String("restart-signal", "TERM", "Signal that asks the command to stop before a --watch restart")
+	runCmd.Flags().Duration("grace-period", 10*time.Second, "Time the command has to stop before it is killed")
+	runCmd.Flags().Bool("no-validate", false, "Start even if secrets do not match the .nvolt.toml schema")
 	rootCmd.AddCommand(runCmd)
 }
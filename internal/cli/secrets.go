@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/iluxav/nvolt/internal/crypto"
+	"github.com/iluxav/nvolt/internal/git"
+	"github.com/iluxav/nvolt/internal/ui"
+	"github.com/iluxav/nvolt/internal/vault"
+)
+
+// decryptEnvironmentSecrets decrypts all secrets of a project environment.
+// Secrets that fail to load or decrypt are reported as warnings and skipped.
+func decryptEnvironmentSecrets(paths *vault.Paths, environment, displayName string) (map[string]string, error) {
+	// Unwrap master key for this project
+	masterKey, err := vault.UnwrapMasterKey(paths, environment)
+	if err != nil {
+		return nil, err
+	}
+	// Clear master key from memory when done
+	defer crypto.ZeroBytes(masterKey)
+
+	// Get list of secret files for this environment
+	secretFiles, err := paths.Storage().ListFiles(paths.GetSecretsPath(environment))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets for project '%s': %w", displayName, err)
+	}
+
+	secrets := make(map[string]string, len(secretFiles))
+	for _, secretFile := range secretFiles {
+		key := vault.GetSecretKeyFromFilename(secretFile)
+
+		// Load and decrypt secret
+		encrypted, err := vault.LoadEncryptedSecret(paths, environment, key)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to load secret %s from project %s: %v\n", key, displayName, err)
+			continue
+		}
+
+		value, err := vault.DecryptSecret(masterKey, encrypted)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to decrypt secret %s from project %s: %v\n", key, displayName, err)
+			continue
+		}
+
+		secrets[key] = value
+	}
+
+	return secrets, nil
+}
+
+// crossProjectLoader returns a loader used to resolve ${project:env:KEY} references.
+// References are resolved against the global vault of the loaded projects.
+func crossProjectLoader(projects []ProjectResolvedInfo) vault.ProjectLoader {
+	return func(project, environment string) (map[string]string, error) {
+		vaultPath := ""
+		for _, p := range projects {
+			if vault.IsGlobalMode(p.VaultPath) {
+				vaultPath = p.VaultPath
+				break
+			}
+		}
+
+		if vaultPath == "" {
+			var err error
+			vaultPath, err = selectGlobalVault()
+			if err != nil {
+				return nil, fmt.Errorf("cross-project references require a global vault: %w", err)
+			}
+		}
+
+		paths := vault.GetVaultPaths(vaultPath, project)
+		return decryptEnvironmentSecrets(paths, environment, project)
+	}
+}
+
+// loadProjectSecrets decrypts and merges the secrets of every project as
+// configured by compose, and resolves references unless noInterpolate. Only
+// references in keys picked by selection must resolve; selection itself is
+// applied by the caller.
+func loadProjectSecrets(projects []ProjectResolvedInfo, environment string, noInterpolate bool, compose composeOptions, selection keySelection) (map[string]string, error) {
+	var layers []secretLayer
+	for _, projectInfo := range projects {
+		ui.Verbose("  Loading secrets from project: %s", ui.Cyan(projectInfo.DisplayName))
+		paths := vault.GetVaultPaths(projectInfo.VaultPath, projectInfo.ProjectName)
+
+		secrets, err := decryptEnvironmentSecrets(paths, environment, projectInfo.DisplayName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unwrap master key for project '%s': %w\nMake sure you have pushed secrets first", projectInfo.DisplayName, err)
+		}
+
+		if len(secrets) == 0 {
+			ui.Warning("No secrets found for project '%s' in environment '%s'", projectInfo.DisplayName, environment)
+			continue
+		}
+
+		layers = append(layers, secretLayer{
+			project: projectInfo.DisplayName,
+			prefix:  projectInfo.Prefix,
+			secrets: secrets,
+		})
+	}
+
+	// Resolve ${KEY} and ${project:env:KEY} references, within each project
+	// before its keys are prefixed
+	if !noInterpolate {
+		var err error
+		layers, err = resolveLayerReferences(layers, compose.onConflict, crossProjectLoader(projects), selection.selects)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve secret references: %w", err)
+		}
+	}
+
+	allSecrets, conflicts := composeSecrets(layers, compose.onConflict)
+	if err := reportConflicts(conflicts, compose.onConflict); err != nil {
+		return nil, err
+	}
+
+	if len(allSecrets) == 0 {
+		return nil, fmt.Errorf("no secrets could be decrypted from any project")
+	}
+
+	return allSecrets, nil
+}
+
+// resolveCommandProjects resolves the projects a command loads secrets from
+// and shows them. With pull, their global vaults are pulled first.
+func resolveCommandProjects(projectSpecs []string, pull bool) ([]ProjectResolvedInfo, error) {
+	projects, err := resolveProjects(projectSpecs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(projects) > 1 {
+		projectNames := make([]string, len(projects))
+		for i, p := range projects {
+			projectNames[i] = p.DisplayName
+		}
+		ui.Info("Loading projects: %s", ui.Cyan(strings.Join(projectNames, ", ")))
+	} else if len(projects) == 1 {
+		ui.PrintDetected("Project", projects[0].DisplayName)
+	}
+
+	if pull {
+		if err := pullProjectVaults(projects); err != nil {
+			return nil, err
+		}
+	}
+
+	warnIfProjectVaultsNewer(projects)
+	return projects, nil
+}
+
+// pullProjectVaults pulls the repository of every global vault used by
+// projects, once per repository
+func pullProjectVaults(projects []ProjectResolvedInfo) error {
+	pulled := make(map[string]bool)
+	for _, p := range projects {
+		if !vault.IsGlobalMode(p.VaultPath) {
+			continue
+		}
+		repoPath := vault.GetRepoPathFromVault(p.VaultPath)
+		if pulled[repoPath] {
+			continue
+		}
+		pulled[repoPath] = true
+
+		ui.Verbose("  Pulling latest changes from %s", repoPath)
+		if err := git.SafePull(repoPath); err != nil {
+			return fmt.Errorf("failed to pull from repository: %w", err)
+		}
+	}
+	return nil
+}
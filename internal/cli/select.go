@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/iluxav/nvolt/internal/ui"
+	"github.com/iluxav/nvolt/internal/validation"
+	"github.com/spf13/cobra"
+)
+
+// keySelection picks and renames secrets after composition (--only,
+// --exclude and --map)
+type keySelection struct {
+	only    []string // glob patterns of keys to keep
+	exclude []string // glob patterns of keys to drop
+	renames []keyRename
+}
+
+// keyRename passes the secret from under the name to
+type keyRename struct {
+	from string
+	to   string
+}
+
+// addSelectionFlags adds the key selection flags to a command loading secrets
+func addSelectionFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("only", []string{}, "Only use these keys (glob patterns like 'DB_*', repeatable)")
+	cmd.Flags().StringSlice("exclude", []string{}, "Leave out these keys (glob patterns, repeatable)")
+	cmd.Flags().StringSlice("map", []string{}, "Pass a secret under another name, as SRC=DST (repeatable)")
+}
+
+// getKeySelection reads and checks the flags added by addSelectionFlags
+func getKeySelection(cmd *cobra.Command) (keySelection, error) {
+	var s keySelection
+	s.only, _ = cmd.Flags().GetStringSlice("only")
+	s.exclude, _ = cmd.Flags().GetStringSlice("exclude")
+	mappings, _ := cmd.Flags().GetStringSlice("map")
+
+	for _, pattern := range append(s.only, s.exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return s, fmt.Errorf("invalid key pattern '%s': %w", pattern, err)
+		}
+	}
+
+	for _, mapping := range mappings {
+		from, to, ok := strings.Cut(mapping, "=")
+		if !ok || from == "" || to == "" {
+			return s, fmt.Errorf("invalid --map '%s': expected SRC=DST", mapping)
+		}
+		if err := validation.ValidateSecretKey(to); err != nil {
+			return s, fmt.Errorf("invalid --map '%s': %w", mapping, err)
+		}
+		s.renames = append(s.renames, keyRename{from: from, to: to})
+	}
+	return s, nil
+}
+
+// empty reports whether the selection keeps every secret unchanged
+func (s keySelection) empty() bool {
+	return len(s.only) == 0 && len(s.exclude) == 0 && len(s.renames) == 0
+}
+
+// selects reports whether the secret key is kept (possibly under another
+// name). Keys named by --map are selected even if --only does not match
+// them; --exclude always wins.
+func (s keySelection) selects(key string) bool {
+	if matchesAny(s.exclude, key) {
+		return false
+	}
+	if len(s.only) == 0 || matchesAny(s.only, key) {
+		return true
+	}
+	for _, r := range s.renames {
+		if r.from == key {
+			return true
+		}
+	}
+	return false
+}
+
+// apply returns the selected and renamed secrets. Patterns and keys that match nothing are reported as warnings.
+func (s keySelection) apply(secrets map[string]string) (map[string]string, error) {
+	if s.empty() {
+		return secrets, nil
+	}
+
+	for _, pattern := range s.only {
+		if !matchesAnyKey(pattern, secrets) {
+			ui.Warning("--only %s matches no secret", pattern)
+		}
+	}
+
+	mapped := make(map[string]bool)
+	for _, r := range s.renames {
+		mapped[r.from] = true
+	}
+
+	selected := make(map[string]string, len(secrets))
+	for key, value := range secrets {
+		if s.selects(key) {
+			selected[key] = value
+		}
+	}
+
+	// Renames read the selected secrets, so SRC=A and SRC=B both work
+	result := make(map[string]string, len(selected))
+	for key, value := range selected {
+		if !mapped[key] {
+			result[key] = value
+		}
+	}
+	for _, r := range s.renames {
+		value, ok := selected[r.from]
+		if !ok {
+			if _, exists := secrets[r.from]; exists {
+				ui.Warning("--map %s=%s: %s is excluded", r.from, r.to, r.from)
+			} else {
+				ui.Warning("--map %s=%s: secret %s not found", r.from, r.to, r.from)
+			}
+			continue
+		}
+		if _, exists := result[r.to]; exists {
+			return nil, fmt.Errorf("--map %s=%s: secret %s already exists; exclude it or map it to another name", r.from, r.to, r.to)
+		}
+		result[r.to] = value
+	}
+
+	if len(result) == 0 {
+		ui.Warning("No secrets left after --only, --exclude and --map")
+	}
+	return result, nil
+}
+
+// matchesAny reports whether key matches one of the glob patterns
+func matchesAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAnyKey reports whether pattern matches one of the keys of secrets
+func matchesAnyKey(pattern string, secrets map[string]string) bool {
+	for key := range secrets {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
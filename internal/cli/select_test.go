@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestKeySelectionApply(t *testing.T) {
+	secrets := map[string]string{
+		"DB_HOST":      "h",
+		"DB_PASSWORD":  "p",
+		"DATABASE_URL": "postgres://",
+		"API_KEY":      "k",
+	}
+
+	tests := []struct {
+		name string
+		sel  keySelection
+		want map[string]string
+	}{
+		{"none", keySelection{}, secrets},
+		{
+			"only",
+			keySelection{only: []string{"DB_*"}},
+			map[string]string{"DB_HOST": "h", "DB_PASSWORD": "p"},
+		},
+		{
+			"exclude",
+			keySelection{exclude: []string{"DB_*", "DATABASE_URL"}},
+			map[string]string{"API_KEY": "k"},
+		},
+		{
+			"map selects its source",
+			keySelection{only: []string{"API_KEY"}, renames: []keyRename{{"DATABASE_URL", "PG_URL"}}},
+			map[string]string{"API_KEY": "k", "PG_URL": "postgres://"},
+		},
+		{
+			"exclude wins over map",
+			keySelection{exclude: []string{"DATABASE_URL"}, renames: []keyRename{{"DATABASE_URL", "PG_URL"}}},
+			map[string]string{"DB_HOST": "h", "DB_PASSWORD": "p", "API_KEY": "k"},
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.sel.apply(secrets)
+		if err != nil {
+			t.Errorf("%s: apply failed: %v", tt.name, err)
+			continue
+		}
+		if !maps.Equal(got, tt.want) {
+			t.Errorf("%s: apply = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Renaming onto an existing key would silently drop a secret
+	sel := keySelection{renames: []keyRename{{"DATABASE_URL", "API_KEY"}}}
+	if _, err := sel.apply(secrets); err == nil {
+		t.Error("apply with --map onto an existing key succeeded, want an error")
+	}
+}
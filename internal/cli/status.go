@@ -72,6 +72,8 @@ func runStatus() error {
 		return nil
 	}
 
+	warnIfVaultNewer(vaultPath)
+
 	mode := vault.GetVaultMode(vaultPath)
 	isGlobal := mode == vault.ModeGlobal
 
@@ -114,7 +116,7 @@ func displayLocalStatus(vaultPath string) error {
 	repoPath := cwd
 
 	// List environments
-	envDirs, err := vault.ListDirs(paths.Secrets)
+	envDirs, err := paths.Storage().ListDirs(paths.Secrets)
 	if err != nil || len(envDirs) == 0 {
 		fmt.Println(valueStyle.Render("No environments found"))
 		return nil
@@ -133,7 +135,7 @@ func displayLocalStatus(vaultPath string) error {
 			continue
 		}
 
-		secretFiles, err := vault.ListFiles(envDir)
+		secretFiles, err := paths.Storage().ListFiles(envDir)
 		if err != nil {
 			continue
 		}
@@ -214,7 +216,7 @@ func displayGlobalStatus(vaultPath string) error {
 		}
 
 		paths := vault.GetVaultPaths(vaultPath, project)
-		envDirs, err := vault.ListDirs(paths.Secrets)
+		envDirs, err := paths.Storage().ListDirs(paths.Secrets)
 		if err != nil {
 			continue
 		}
@@ -229,7 +231,7 @@ func displayGlobalStatus(vaultPath string) error {
 				continue
 			}
 
-			secretFiles, err := vault.ListFiles(envDir)
+			secretFiles, err := paths.Storage().ListFiles(envDir)
 			if err != nil {
 				continue
 			}
@@ -295,7 +297,7 @@ func displayMachineAccess(paths *vault.Paths, projectName string) error {
 	var rows [][]string
 
 	// Get all environments to check for wrapped keys
-	envDirs, err := vault.ListDirs(paths.Secrets)
+	envDirs, err := paths.Storage().ListDirs(paths.Secrets)
 	if err != nil {
 		return err
 	}
@@ -313,7 +315,7 @@ func displayMachineAccess(paths *vault.Paths, projectName string) error {
 			}
 
 			wrappedKeyPath := paths.GetWrappedKeyPath(envName, machine.ID)
-			if _, err := os.Stat(wrappedKeyPath); err == nil {
+			if paths.Storage().Exists(wrappedKeyPath) {
 				accessibleEnvs = append(accessibleEnvs, envName)
 			}
 		}
@@ -359,7 +361,7 @@ func displayGlobalMachineAccess(vaultPath string, projects []string) error {
 			var accessibleEnvs []string
 
 			// Get all environments for this project
-			envDirs, err := vault.ListDirs(paths.Secrets)
+			envDirs, err := paths.Storage().ListDirs(paths.Secrets)
 			if err != nil {
 				continue
 			}
@@ -374,7 +376,7 @@ func displayGlobalMachineAccess(vaultPath string, projects []string) error {
 				}
 
 				wrappedKeyPath := paths.GetWrappedKeyPath(envName, machine.ID)
-				if _, err := os.Stat(wrappedKeyPath); err == nil {
+				if paths.Storage().Exists(wrappedKeyPath) {
 					accessibleEnvs = append(accessibleEnvs, envName)
 				}
 			}
@@ -44,6 +44,13 @@ func runSync(rotate bool, environment string, autoGrant bool) error {
 		return err
 	}
 
+	// Hold the vault lock for the duration of the command
+	unlock, err := lockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Pull latest changes in global mode BEFORE doing any work
 	// This ensures we have the latest machine keys from other machines
 	if vault.IsGlobalMode(vaultPath) {
@@ -55,6 +62,11 @@ func runSync(rotate bool, environment string, autoGrant bool) error {
 		ui.Success("Repository up to date")
 	}
 
+	// Refuse to modify vaults written by a newer nvolt
+	if err := ensureVaultWritable(vaultPath); err != nil {
+		return err
+	}
+
 	// Get current machine info
 	machineInfo, err := vault.LoadMachineInfo()
 	if err != nil {
@@ -77,6 +89,14 @@ func runSync(rotate bool, environment string, autoGrant bool) error {
 
 	paths := vault.GetVaultPaths(vaultPath, project)
 
+	// Stage all writes so a rotation either fully applies or leaves the old key in place
+	tx, err := vault.BeginTransaction(vaultPath, fmt.Sprintf("sync %s", environment))
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	txPaths := paths.WithTransaction(tx)
+
 	var masterKey []byte
 
 	if rotate {
@@ -95,7 +115,7 @@ func runSync(rotate bool, environment string, autoGrant bool) error {
 		}
 
 		// Re-encrypt secrets in this environment with new key
-		if err := rotateSecretsEncryption(paths, environment, oldMasterKey, masterKey); err != nil {
+		if err := rotateSecretsEncryption(txPaths, environment, oldMasterKey, masterKey); err != nil {
 			return fmt.Errorf("failed to re-encrypt secrets: %w", err)
 		}
 
@@ -118,10 +138,14 @@ func runSync(rotate bool, environment string, autoGrant bool) error {
 	} else {
 		ui.Step("Wrapping master key for machines")
 	}
-	if err := vault.WrapMasterKeyForMachines(paths, environment, masterKey, machineInfo.ID, autoGrant); err != nil {
+	if err := vault.WrapMasterKeyForMachines(txPaths, environment, masterKey, machineInfo.ID, autoGrant); err != nil {
 		return fmt.Errorf("failed to wrap master key: %w", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to apply changes: %w", err)
+	}
+
 	// List machines to show what was done
 	machines, err := vault.ListMachines(paths)
 	if err != nil {
@@ -168,7 +192,7 @@ func runSync(rotate bool, environment string, autoGrant bool) error {
 // rotateSecretsEncryption re-encrypts all secrets in a specific environment with a new master key
 func rotateSecretsEncryption(paths *vault.Paths, environment string, oldKey, newKey []byte) error {
 	// List all secrets in this environment
-	secretFiles, err := vault.ListFiles(paths.GetSecretsPath(environment))
+	secretFiles, err := paths.Storage().ListFiles(paths.GetSecretsPath(environment))
 	if err != nil {
 		return fmt.Errorf("failed to list secrets: %w", err)
 	}
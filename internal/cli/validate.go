@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/iluxav/nvolt/internal/config"
+	"github.com/iluxav/nvolt/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check secrets against the schema in .nvolt.toml",
+	Long: `Decrypt the secrets of an environment and check them against the schema
+declared in the [secrets] table of .nvolt.toml. Fails if a required secret is
+missing or a value does not match its type.
+
+Examples:
+  nvolt validate -e production
+  nvolt validate -e staging -p shared -p api`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		environment, _ := cmd.Flags().GetString("env")
+		projects, _ := cmd.Flags().GetStringSlice("project")
+		compose, err := getComposeOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		return runValidate(environment, projects, compose)
+	},
+}
+
+func runValidate(environment string, projects []string, compose composeOptions) error {
+	if !projectManifest.HasSchema() {
+		return fmt.Errorf("no secret schema found: declare secrets in the [secrets] table of %s", config.ManifestFile)
+	}
+
+	// Ensure machine is initialized
+	if err := EnsureMachineInitialized(); err != nil {
+		return err
+	}
+
+	ui.Step("Validating secrets of environment '%s'", ui.Cyan(environment))
+
+	projectsToLoad, err := resolveCommandProjects(projects, true)
+	if err != nil {
+		return err
+	}
+
+	secrets, err := loadProjectSecrets(projectsToLoad, environment, false, compose, keySelection{})
+	if err != nil {
+		return err
+	}
+
+	if err := checkSecretSchema(environment, secrets, false); err != nil {
+		return err
+	}
+
+	if undeclared := projectManifest.UndeclaredSecrets(secrets); len(undeclared) > 0 {
+		ui.Warning("Secrets not declared in the schema: %s", strings.Join(undeclared, ", "))
+	}
+
+	ui.Success("All secrets match the schema in %s", projectManifest.Path)
+	return nil
+}
+
+func init() {
+	validateCmd.Flags().StringP("env", "e", "default", "Environment name")
+	validateCmd.Flags().StringSliceP("project", "p", []string{}, "Project name(s) or org/repo:project - can be specified multiple times for composition")
+	addComposeFlags(validateCmd)
+	rootCmd.AddCommand(validateCmd)
+}
@@ -1,9 +1,15 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/iluxav/nvolt/internal/config"
+	"github.com/iluxav/nvolt/internal/git"
 	"github.com/iluxav/nvolt/internal/ui"
 	"github.com/iluxav/nvolt/internal/vault"
 	"github.com/spf13/cobra"
@@ -40,6 +46,89 @@ This checks:
 	},
 }
 
+var vaultListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the vaults available on this machine",
+	Long: `List the local vault of the current directory and every global vault
+cloned under ~/.nvolt/orgs, marking the one commands will use.
+
+A global vault is selected, in order of precedence, by:
+  --vault org/repo
+  NVOLT_VAULT=org/repo
+  vault in the project's .nvolt.toml
+  the directory default set with 'nvolt vault use'
+  the only cloned global vault, if there is exactly one
+  defaults.vault in ~/.nvolt/config.toml, if several are cloned
+
+An explicitly selected global vault takes precedence over a local .nvolt vault;
+defaults.vault does not.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runVaultList()
+	},
+}
+
+var vaultUseCmd = &cobra.Command{
+	Use:   "use [org/repo]",
+	Short: "Set the default global vault for the current directory",
+	Long: `Set the global vault used in the current directory and its subdirectories.
+The default is stored on this machine only (~/.nvolt/directories.json).
+
+Examples:
+  nvolt vault use acme/secrets   # Use acme/secrets here
+  nvolt vault use                # Show the default for this directory
+  nvolt vault use --unset        # Remove the default`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		unset, _ := cmd.Flags().GetBool("unset")
+		spec := ""
+		if len(args) > 0 {
+			spec = args[0]
+		}
+		return runVaultUse(spec, unset)
+	},
+}
+
+var vaultMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Migrate a vault between local and global mode",
+	Long: `Copy machines, secrets and wrapped keys between a local .nvolt vault
+and a project in a global vault repository.
+
+The source vault is left untouched. Machine lists are merged, and the copy is
+only applied once every secret decrypts in the target layout; in global mode
+the result is committed and pushed.
+
+Examples:
+  nvolt vault migrate --to org/repo --project myproject   # local -> global
+  nvolt vault migrate --to local --from org/repo -p myproject  # global -> local`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		to, _ := cmd.Flags().GetString("to")
+		from, _ := cmd.Flags().GetString("from")
+		project, _ := cmd.Flags().GetString("project")
+		return runVaultMigrate(to, from, project)
+	},
+}
+
+var vaultUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade the vault to the current schema version",
+	Long: `Apply pending vault format migrations in order.
+
+Each migration is idempotent and the schema version recorded in config.json
+is bumped after every step, so an interrupted upgrade can simply be re-run.
+
+Examples:
+  nvolt vault upgrade --dry-run   # Show what would change
+  nvolt vault upgrade`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		return runVaultUpgrade(dryRun)
+	},
+}
+
 func runVaultShow() error {
 	// Find vault path
 	vaultPath, err := findVaultPath()
@@ -47,6 +136,8 @@ func runVaultShow() error {
 		return err
 	}
 
+	warnIfVaultNewer(vaultPath)
+
 	// TODO: In global mode with multiple projects, this should show project-specific info
 	// For now, we use empty project name which works for local mode
 	paths := vault.GetVaultPaths(vaultPath, "")
@@ -56,6 +147,10 @@ func runVaultShow() error {
 
 	// Show vault location
 	ui.PrintKeyValue("Vault Location", ui.Gray(vaultPath))
+	ui.PrintKeyValue("Storage", vault.StorageKind(paths.Storage()))
+	if version, err := vault.GetSchemaVersion(vaultPath); err == nil {
+		ui.PrintKeyValue("Schema Version", fmt.Sprintf("%d (supported: %d)", version, vault.SchemaVersion))
+	}
 	fmt.Println()
 
 	// Get current machine info
@@ -77,7 +172,7 @@ func runVaultShow() error {
 	}
 
 	// List environments first to show access per environment
-	envDirs, err := vault.ListDirs(paths.Secrets)
+	envDirs, err := paths.Storage().ListDirs(paths.Secrets)
 	if err != nil {
 		ui.Warning("Could not list environments: %v", err)
 		envDirs = []string{}
@@ -107,7 +202,7 @@ func runVaultShow() error {
 				ui.Info("    Access:")
 				for _, env := range environments {
 					wrappedKeyPath := paths.GetWrappedKeyPath(env, m.ID)
-					hasKey := vault.FileExists(wrappedKeyPath)
+					hasKey := paths.Storage().Exists(wrappedKeyPath)
 					if hasKey {
 						ui.Info(fmt.Sprintf("      %s: %s", ui.Cyan(env), ui.BrightGreen("✓")))
 					} else {
@@ -123,7 +218,7 @@ func runVaultShow() error {
 	fmt.Println()
 
 	// List environments
-	envDirs, err = vault.ListDirs(paths.Secrets)
+	envDirs, err = paths.Storage().ListDirs(paths.Secrets)
 	if err != nil {
 		ui.Warning("Environments: (error listing: %v)", err)
 		fmt.Println()
@@ -134,7 +229,7 @@ func runVaultShow() error {
 		ui.Section(fmt.Sprintf("Environments (%d):", len(envDirs)))
 		for _, envDir := range envDirs {
 			envName := vault.GetDirName(envDir)
-			secretFiles, err := vault.ListFiles(envDir)
+			secretFiles, err := paths.Storage().ListFiles(envDir)
 			if err != nil {
 				ui.Substep(fmt.Sprintf("%s %s", ui.Cyan(envName), ui.Red(fmt.Sprintf("(error: %v)", err))))
 			} else {
@@ -154,6 +249,8 @@ func runVaultVerify() error {
 		return err
 	}
 
+	warnIfVaultNewer(vaultPath)
+
 	// TODO: In global mode with multiple projects, this should verify all projects
 	// For now, we use empty project name which works for local mode
 	paths := vault.GetVaultPaths(vaultPath, "")
@@ -171,6 +268,19 @@ func runVaultVerify() error {
 	} else {
 		ui.Success("Vault structure is valid")
 	}
+	if version, err := vault.GetSchemaVersion(vaultPath); err == nil && version < vault.SchemaVersion {
+		warnings = append(warnings, fmt.Sprintf("Vault uses schema version %d. Run 'nvolt vault upgrade' to update it to %d", version, vault.SchemaVersion))
+	}
+
+	// Check names that could escape their directory in the vault
+	ui.Step("Checking names")
+	if invalid, err := vault.FindInvalidNames(vaultPath); err != nil {
+		errors = append(errors, fmt.Sprintf("Cannot check names: %v", err))
+	} else if len(invalid) > 0 {
+		errors = append(errors, invalid...)
+	} else {
+		ui.Success("All projects, environments, secret keys and machine IDs are valid")
+	}
 
 	// Check Git security
 	ui.Step("Checking Git security")
@@ -201,7 +311,7 @@ func runVaultVerify() error {
 		ui.Success(fmt.Sprintf("Current machine: %s", ui.Cyan(currentMachine.ID)))
 
 		// List environments to check access
-		envDirs, err := vault.ListDirs(paths.Secrets)
+		envDirs, err := paths.Storage().ListDirs(paths.Secrets)
 		if err == nil && len(envDirs) > 0 {
 			ui.Info("Checking access to environments...")
 			for _, envDir := range envDirs {
@@ -225,7 +335,7 @@ func runVaultVerify() error {
 		ui.Success(fmt.Sprintf("Found %d machine(s)", len(machines)))
 
 		// Get list of environments
-		envDirs, err := vault.ListDirs(paths.Secrets)
+		envDirs, err := paths.Storage().ListDirs(paths.Secrets)
 		environments := []string{}
 		if err == nil {
 			for _, envDir := range envDirs {
@@ -239,7 +349,7 @@ func runVaultVerify() error {
 				hasAnyKey := false
 				for _, env := range environments {
 					wrappedKeyPath := paths.GetWrappedKeyPath(env, m.ID)
-					if vault.FileExists(wrappedKeyPath) {
+					if paths.Storage().Exists(wrappedKeyPath) {
 						hasAnyKey = true
 						break
 					}
@@ -255,7 +365,7 @@ func runVaultVerify() error {
 	ui.Step("Checking wrapped keys")
 
 	// Get list of environments
-	envDirs, err := vault.ListDirs(paths.Secrets)
+	envDirs, err := paths.Storage().ListDirs(paths.Secrets)
 	if err != nil {
 		errors = append(errors, fmt.Sprintf("Cannot list environments: %v", err))
 	} else {
@@ -272,7 +382,7 @@ func runVaultVerify() error {
 			envName := vault.GetDirName(envDir)
 			wrappedKeysEnvPath := paths.GetWrappedKeysEnvPath(envName)
 
-			wrappedKeyFiles, err := vault.ListFiles(wrappedKeysEnvPath)
+			wrappedKeyFiles, err := paths.Storage().ListFiles(wrappedKeysEnvPath)
 			if err != nil {
 				// Skip if directory doesn't exist
 				continue
@@ -299,13 +409,13 @@ func runVaultVerify() error {
 
 	// Check secrets
 	ui.Step("Checking secrets")
-	envDirs, err = vault.ListDirs(paths.Secrets)
+	envDirs, err = paths.Storage().ListDirs(paths.Secrets)
 	if err != nil {
 		errors = append(errors, fmt.Sprintf("Cannot list environments: %v", err))
 	} else {
 		totalSecrets := 0
 		for _, envDir := range envDirs {
-			secretFiles, err := vault.ListFiles(envDir)
+			secretFiles, err := paths.Storage().ListFiles(envDir)
 			if err != nil {
 				warnings = append(warnings, fmt.Sprintf("Cannot list secrets in %s: %v", envDir, err))
 				continue
@@ -347,8 +457,477 @@ func runVaultVerify() error {
 	return nil
 }
 
+func runVaultMigrate(to, from, project string) error {
+	if err := EnsureMachineInitialized(); err != nil {
+		return err
+	}
+
+	if to == "" {
+		return fmt.Errorf("--to is required: use 'local' or 'org/repo'")
+	}
+
+	localPath, err := vault.GetLocalVaultPath()
+	if err != nil {
+		return fmt.Errorf("failed to get local vault path: %w", err)
+	}
+
+	var sourcePath, targetPath string
+	if to == "local" {
+		// Global -> local
+		if from != "" {
+			sourcePath, err = getClonedGlobalVault(from)
+		} else {
+			sourcePath, err = selectGlobalVault()
+		}
+		if err != nil {
+			return err
+		}
+		targetPath = localPath
+	} else {
+		// Local -> global
+		if !vault.IsVaultInitialized(localPath) {
+			return fmt.Errorf("no local vault found at %s", localPath)
+		}
+		sourcePath = localPath
+		targetPath, err = getClonedGlobalVault(to)
+		if err != nil {
+			return err
+		}
+	}
+
+	if project == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		project, _, err = config.GetProjectName(cwd, "")
+		if err != nil {
+			return fmt.Errorf("failed to detect project name. Use -p flag to specify: %w", err)
+		}
+	}
+
+	ui.Step("Migrating vault")
+	ui.PrintKeyValue("  From", ui.Gray(sourcePath))
+	ui.PrintKeyValue("  To", ui.Gray(targetPath))
+	ui.PrintKeyValue("  Project", ui.Cyan(project))
+
+	// Hold both vault locks for the duration of the migration
+	for _, path := range []string{targetPath, sourcePath} {
+		unlock, err := lockVault(path)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	// Pull latest changes of the global side before doing any work
+	globalPath := targetPath
+	if to == "local" {
+		globalPath = sourcePath
+	}
+	repoPath := vault.GetRepoPathFromVault(globalPath)
+	ui.Step("Pulling latest changes from repository")
+	if err := git.SafePull(repoPath); err != nil {
+		return fmt.Errorf("failed to pull latest changes: %w", err)
+	}
+	ui.Success("Repository up to date")
+
+	if vault.IsVaultInitialized(targetPath) {
+		if err := ensureVaultWritable(targetPath); err != nil {
+			return err
+		}
+	} else {
+		if err := vault.InitializeVaultDirectory(targetPath); err != nil {
+			return fmt.Errorf("failed to initialize vault directory: %w", err)
+		}
+		if err := vault.SaveVaultConfig(targetPath, vault.NewVaultConfig(targetPath)); err != nil {
+			return err
+		}
+	}
+
+	source := vault.GetVaultPaths(sourcePath, project)
+	target := vault.GetVaultPaths(targetPath, project)
+
+	result, err := vault.MigrateVault(source, target)
+	if err != nil {
+		return fmt.Errorf("failed to migrate vault: %w", err)
+	}
+
+	ui.Success("Copied %d secret(s) and %d wrapped key(s) across %d environment(s)",
+		result.Secrets, result.WrappedKeys, len(result.Environments))
+	ui.Success("Merged machines: %d added, %d already registered", len(result.MachinesAdded), len(result.MachinesSkipped))
+
+	// MigrateVault only applies the copy once it decrypts in the target layout
+	ui.Step("Verified migrated secrets")
+	for _, env := range result.Environments {
+		if slices.Contains(result.Unverified, env) {
+			ui.Warning("Cannot verify '%s': this machine has no access to the environment", env)
+			continue
+		}
+		ui.Info(fmt.Sprintf("  %s %s (%d secret(s))", ui.BrightGreen("✓"), ui.Cyan(env), result.Verified[env]))
+	}
+
+	if to != "local" {
+		ui.Step("Committing and pushing changes to repository")
+		commitMsg := fmt.Sprintf("Migrate local vault into project '%s'", project)
+		if err := git.CommitAndPush(repoPath, commitMsg, project, vault.MachinesDir); err != nil {
+			return fmt.Errorf("failed to commit and push changes: %w", err)
+		}
+		ui.Success("Changes committed and pushed")
+	}
+
+	fmt.Println()
+	ui.Success("Migration complete")
+	ui.Info(ui.Gray(fmt.Sprintf("The source vault at %s was not modified. Remove it once you have verified the migration.", sourcePath)))
+
+	return nil
+}
+
+func runVaultUpgrade(dryRun bool) error {
+	vaultPath, err := findVaultPath()
+	if err != nil {
+		return err
+	}
+
+	// Hold the vault lock for the duration of the command
+	unlock, err := lockVault(vaultPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if vault.IsGlobalMode(vaultPath) {
+		repoPath := vault.GetRepoPathFromVault(vaultPath)
+		ui.Step("Pulling latest changes from repository")
+		if err := git.SafePull(repoPath); err != nil {
+			return fmt.Errorf("failed to pull latest changes: %w", err)
+		}
+		ui.Success("Repository up to date")
+	}
+
+	version, err := vault.GetSchemaVersion(vaultPath)
+	if err != nil {
+		return err
+	}
+	ui.PrintKeyValue("  Current schema version", fmt.Sprintf("%d", version))
+	ui.PrintKeyValue("  Supported schema version", fmt.Sprintf("%d", vault.SchemaVersion))
+
+	if dryRun {
+		ui.Warning("[DRY RUN] Simulating vault upgrade")
+	}
+
+	steps, err := vault.UpgradeVault(vaultPath, dryRun)
+	for _, step := range steps {
+		ui.Step("v%d: %s", step.Version, step.Description)
+		for _, action := range step.Actions {
+			ui.Substep(action)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to upgrade vault: %w", err)
+	}
+
+	if len(steps) == 0 {
+		ui.Success("Vault is up to date")
+		return nil
+	}
+
+	if dryRun {
+		ui.Info(ui.Gray("\n[DRY RUN] No changes were made"))
+		return nil
+	}
+
+	ui.Success("Vault upgraded to schema version %d", vault.SchemaVersion)
+
+	if vault.IsGlobalMode(vaultPath) {
+		repoPath := vault.GetRepoPathFromVault(vaultPath)
+		ui.Step("Committing and pushing changes to repository")
+		commitMsg := fmt.Sprintf("Upgrade vault to schema version %d", vault.SchemaVersion)
+		if err := git.CommitAndPush(repoPath, commitMsg, "."); err != nil {
+			return fmt.Errorf("failed to commit and push changes: %w", err)
+		}
+		ui.Success("Changes committed and pushed")
+	}
+
+	return nil
+}
+
+// lockVault takes the vault lock for a mutating command, waiting up to --wait,
+// and recovers any interrupted transaction. The returned function releases the lock.
+func lockVault(vaultPath string) (func(), error) {
+	lock, err := vault.LockVault(vaultPath, lockWait)
+	if err != nil {
+		return nil, err
+	}
+	ui.Debug("Acquired vault lock")
+
+	// Finish or roll back a transaction interrupted by a previous command
+	recovery, err := vault.RecoverTransaction(vaultPath)
+	if err != nil {
+		lock.Unlock()
+		return nil, fmt.Errorf("failed to recover interrupted transaction: %w", err)
+	}
+	if recovery != nil && recovery.RolledBack {
+		ui.Warning("Rolled back an interrupted vault transaction")
+	} else if recovery != nil {
+		ui.Warning("Completed interrupted vault transaction '%s' (%d operations)", recovery.Description, recovery.Operations)
+	}
+
+	return func() {
+		if err := lock.Unlock(); err != nil {
+			ui.Warning("Failed to release vault lock: %v", err)
+		}
+	}, nil
+}
+
+// ensureVaultWritable refuses to modify vaults written by a newer nvolt
+// and, in verbose mode, suggests 'nvolt vault upgrade' for vaults with an older schema
+func ensureVaultWritable(vaultPath string) error {
+	if err := vault.CheckCompatibility(vaultPath, true); err != nil {
+		return err
+	}
+
+	// 'nvolt vault verify' reports outdated vaults; don't repeat it on every write
+	if version, err := vault.GetSchemaVersion(vaultPath); err == nil && version < vault.SchemaVersion {
+		ui.Verbose("Vault uses schema version %d. Run 'nvolt vault upgrade' to update it.", version)
+	}
+
+	return nil
+}
+
+// warnIfVaultNewer warns when reading a vault written by a newer nvolt
+func warnIfVaultNewer(vaultPath string) {
+	version, err := vault.GetSchemaVersion(vaultPath)
+	if err != nil {
+		ui.Warning("Could not read vault config: %v", err)
+		return
+	}
+	if version > vault.SchemaVersion {
+		ui.Warning("Vault schema version %d is newer than the supported version %d. Upgrade nvolt; some data may not be readable.", version, vault.SchemaVersion)
+	}
+}
+
+// warnIfProjectVaultsNewer warns once per vault used by a set of resolved projects
+func warnIfProjectVaultsNewer(projects []ProjectResolvedInfo) {
+	checked := make(map[string]bool)
+	for _, p := range projects {
+		if checked[p.VaultPath] {
+			continue
+		}
+		checked[p.VaultPath] = true
+		warnIfVaultNewer(p.VaultPath)
+	}
+}
+
+// errNoGlobalVault is returned by selectGlobalVault when nothing is cloned
+var errNoGlobalVault = errors.New("no global vaults found. Run 'nvolt init --repo org/repo' first")
+
+// selectedVaultSpec returns the explicitly selected global vault (org/repo)
+// and where the selection came from, or "" if none was selected
+func selectedVaultSpec() (string, string, error) {
+	if vaultFlag != "" {
+		return vaultFlag, "--vault", nil
+	}
+
+	if spec := os.Getenv("NVOLT_VAULT"); spec != "" {
+		return spec, "NVOLT_VAULT", nil
+	}
+
+	if projectManifest != nil && projectManifest.Vault != "" {
+		return projectManifest.Vault, projectManifest.Path, nil
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	spec, dir, err := vault.GetDirectoryVault(cwd)
+	if err != nil {
+		return "", "", err
+	}
+	if spec != "" {
+		return spec, fmt.Sprintf("default for %s", dir), nil
+	}
+
+	return "", "", nil
+}
+
+// defaultVaultSpec returns defaults.vault from the user config, which picks
+// a global vault when several are cloned and none was selected
+func defaultVaultSpec() string {
+	spec, _ := userConfig.Get("defaults.vault")
+	return spec
+}
+
+// selectGlobalVault returns the selected global vault, or the only cloned one,
+// or defaults.vault among several cloned ones
+func selectGlobalVault() (string, error) {
+	spec, _, err := selectedVaultSpec()
+	if err != nil {
+		return "", err
+	}
+	if spec != "" {
+		return getClonedGlobalVault(spec)
+	}
+
+	vaults, err := vault.ListGlobalVaults()
+	if err != nil {
+		return "", err
+	}
+
+	switch len(vaults) {
+	case 0:
+		return "", errNoGlobalVault
+	case 1:
+		return vaults[0].Path, nil
+	}
+
+	if spec := defaultVaultSpec(); spec != "" {
+		return getClonedGlobalVault(spec)
+	}
+
+	specs := make([]string, len(vaults))
+	for i, v := range vaults {
+		specs[i] = v.Spec()
+	}
+	return "", fmt.Errorf("multiple global vaults found (%s): select one with --vault, NVOLT_VAULT or 'nvolt vault use'", strings.Join(specs, ", "))
+}
+
+func runVaultList() error {
+	vaults, err := vault.ListGlobalVaults()
+	if err != nil {
+		return err
+	}
+
+	selectedSpec, source, err := selectedVaultSpec()
+	if err != nil {
+		return err
+	}
+
+	// Without an explicit selection, a local vault or the only global vault is used
+	localPath, err := vault.GetLocalVaultPath()
+	hasLocal := err == nil && vault.IsVaultInitialized(localPath)
+	if selectedSpec == "" && !hasLocal {
+		if len(vaults) == 1 {
+			selectedSpec, source = vaults[0].Spec(), "only vault"
+		} else if spec := defaultVaultSpec(); spec != "" && len(vaults) > 1 {
+			selectedSpec, source = spec, config.UserConfigFile
+		}
+	}
+
+	var rows [][]string
+	if hasLocal {
+		status := ""
+		if selectedSpec == "" {
+			status = "selected (current directory)"
+		}
+		rows = append(rows, []string{"(local)", localPath, "-", status})
+	}
+
+	for _, v := range vaults {
+		projects, err := vault.ListProjects(v.Path)
+		projectCount := fmt.Sprintf("%d", len(projects))
+		if err != nil {
+			projectCount = "?"
+		}
+
+		status := ""
+		if v.Spec() == selectedSpec {
+			status = fmt.Sprintf("selected (%s)", source)
+		}
+		rows = append(rows, []string{v.Spec(), v.Path, projectCount, status})
+	}
+
+	if len(rows) == 0 {
+		ui.Info("No vaults found. Run 'nvolt init' or 'nvolt init --repo org/repo' first")
+		return nil
+	}
+
+	fmt.Println(headerStyle.Render("Vaults"))
+	fmt.Println(renderTable([]string{"Vault", "Location", "Projects", "Status"}, rows))
+
+	if selectedSpec == "" && !hasLocal && len(vaults) > 1 {
+		ui.Warning("Multiple global vaults: select one with --vault, NVOLT_VAULT or 'nvolt vault use'")
+	}
+
+	return nil
+}
+
+func runVaultUse(spec string, unset bool) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	if unset {
+		if err := vault.SetDirectoryVault(cwd, ""); err != nil {
+			return err
+		}
+		ui.Success("Removed the default vault for %s", cwd)
+		return nil
+	}
+
+	if spec == "" {
+		current, dir, err := vault.GetDirectoryVault(cwd)
+		if err != nil {
+			return err
+		}
+		if current == "" {
+			ui.Info("No default vault for %s", cwd)
+			return nil
+		}
+		ui.PrintKeyValue("Default vault", current)
+		ui.PrintKeyValue("Set on", ui.Gray(dir))
+		return nil
+	}
+
+	// Only cloned vaults can be selected
+	if _, err := getClonedGlobalVault(spec); err != nil {
+		return err
+	}
+
+	if err := vault.SetDirectoryVault(cwd, spec); err != nil {
+		return err
+	}
+
+	ui.Success("Default vault for %s set to %s", cwd, spec)
+	return nil
+}
+
+// getClonedGlobalVault returns the path of an already cloned global vault
+func getClonedGlobalVault(repoSpec string) (string, error) {
+	org, repo, err := git.GetRepoPath(repoSpec)
+	if err != nil {
+		return "", err
+	}
+
+	vaultPath, err := vault.GetGlobalVaultPath(org, repo)
+	if err != nil {
+		return "", err
+	}
+
+	if !git.IsGitRepo(vaultPath) {
+		return "", fmt.Errorf("global vault %s/%s not found. Run 'nvolt init --repo %s/%s' first", org, repo, org, repo)
+	}
+
+	return vaultPath, nil
+}
+
 func init() {
+	vaultMigrateCmd.Flags().String("to", "", "Target: 'local' or a global repository (org/repo)")
+	vaultMigrateCmd.Flags().String("from", "", "Source global repository (org/repo) when migrating to local")
+	vaultMigrateCmd.Flags().StringP("project", "p", "", "Project name in the global vault (auto-detected if not specified)")
+
+	vaultUseCmd.Flags().Bool("unset", false, "Remove the default vault for the current directory")
+
+	vaultCmd.AddCommand(vaultListCmd)
+	vaultCmd.AddCommand(vaultUseCmd)
 	vaultCmd.AddCommand(vaultShowCmd)
 	vaultCmd.AddCommand(vaultVerifyCmd)
+	vaultCmd.AddCommand(vaultMigrateCmd)
+
+	vaultUpgradeCmd.Flags().Bool("dry-run", false, "Show pending migrations without applying them")
+	vaultCmd.AddCommand(vaultUpgradeCmd)
 	rootCmd.AddCommand(vaultCmd)
 }
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/iluxav/nvolt/internal/config"
+	"github.com/iluxav/nvolt/internal/vault"
+)
+
+func TestDefaultVaultIsTieBreaker(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("NVOLT_CONFIG", home)
+	t.Setenv("NVOLT_VAULT", "")
+	t.Chdir(t.TempDir())
+
+	// Cloned global vaults are Git repositories
+	for _, repo := range []string{"app", "infra"} {
+		repoPath := filepath.Join(home, vault.OrgsDir, "acme", repo)
+		if err := vault.InitializeVaultDirectory(repoPath); err != nil {
+			t.Fatalf("Failed to initialize global vault: %v", err)
+		}
+		if out, err := exec.Command("git", "init", "-q", repoPath).CombinedOutput(); err != nil {
+			t.Fatalf("git init failed: %v\n%s", err, out)
+		}
+	}
+
+	saved := userConfig
+	t.Cleanup(func() { userConfig = saved })
+	userConfig = &config.UserConfig{}
+	if err := userConfig.Set("defaults.vault", "acme/infra"); err != nil {
+		t.Fatalf("Failed to set defaults.vault: %v", err)
+	}
+
+	// Among several cloned vaults, defaults.vault picks one
+	vaultPath, err := findVaultPath()
+	if err != nil {
+		t.Fatalf("findVaultPath failed: %v", err)
+	}
+	if want := filepath.Join(home, vault.OrgsDir, "acme", "infra"); vaultPath != want {
+		t.Errorf("Expected %s, got %s", want, vaultPath)
+	}
+
+	// The local vault of the current directory wins over defaults.vault
+	localPath, err := vault.GetLocalVaultPath()
+	if err != nil {
+		t.Fatalf("Failed to get local vault path: %v", err)
+	}
+	if err := vault.InitializeVaultDirectory(localPath); err != nil {
+		t.Fatalf("Failed to initialize local vault: %v", err)
+	}
+	if vaultPath, err = findVaultPath(); err != nil {
+		t.Fatalf("findVaultPath failed: %v", err)
+	}
+	if vaultPath != localPath {
+		t.Errorf("Expected local vault %s, got %s", localPath, vaultPath)
+	}
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/iluxav/nvolt/internal/process"
+	"github.com/iluxav/nvolt/internal/ui"
+)
+
+// watchAndRestart runs the command and restarts it whenever the secrets
+// returned by load change. It returns when the command exits on its own.
+func watchAndRestart(
+	projects []ProjectResolvedInfo,
+	secrets map[string]string,
+	load func() (map[string]string, error),
+	start func(map[string]string) (*process.Child, error),
+	opts runOptions,
+) error {
+	child, err := start(secrets)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(opts.watchInterval)
+	defer ticker.Stop()
+
+	// Only report a failing check again once its error changes
+	var lastErr string
+	for {
+		select {
+		case <-child.Done():
+			return child.Wait()
+		case <-ticker.C:
+		}
+
+		updated, err := reloadSecrets(projects, load)
+		if err != nil {
+			if err.Error() != lastErr {
+				ui.Warning("Not restarting: %v", err)
+				lastErr = err.Error()
+			}
+			continue
+		}
+		lastErr = ""
+
+		changed := changedKeys(secrets, updated)
+		if len(changed) == 0 {
+			continue
+		}
+
+		ui.Info("Secrets changed: %s", ui.Cyan(strings.Join(changed, ", ")))
+		ui.Step("Restarting command (pid %d)", child.Pid())
+
+		// The old command's status is irrelevant once it is replaced
+		_ = child.Stop(opts.restartSignal, opts.gracePeriod)
+
+		secrets = updated
+		if child, err = start(secrets); err != nil {
+			return err
+		}
+	}
+}
+
+// reloadSecrets pulls global vaults and loads the secrets again. Reaping is
+// held meanwhile, since both run git while nvolt may be PID 1.
+func reloadSecrets(projects []ProjectResolvedInfo, load func() (map[string]string, error)) (map[string]string, error) {
+	release := process.HoldReaping()
+	defer release()
+
+	if err := pullProjectVaults(projects); err != nil {
+		return nil, err
+	}
+
+	// Warnings about the secrets were shown at startup; don't repeat them on
+	// every check
+	if level := ui.GetLevel(); level > ui.LevelError {
+		ui.SetLevel(ui.LevelError)
+		defer ui.SetLevel(level)
+	}
+	return load()
+}
+
+// changedKeys returns the sorted keys that were added, removed or changed
+func changedKeys(old, updated map[string]string) []string {
+	var keys []string
+	for key, value := range updated {
+		if oldValue, ok := old[key]; !ok || oldValue != value {
+			keys = append(keys, key)
+		}
+	}
+	for key := range old {
+		if _, ok := updated[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
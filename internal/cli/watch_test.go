@@ -0,0 +1,110 @@
+//go:build !windows
+
+package cli
+
+import (
+	"errors"
+	"os/exec"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/iluxav/nvolt/internal/process"
+)
+
+func TestChangedKeys(t *testing.T) {
+	old := map[string]string{"KEEP": "1", "CHANGE": "a", "REMOVE": "x"}
+	updated := map[string]string{"KEEP": "1", "CHANGE": "b", "ADD": "y"}
+
+	want := []string{"ADD", "CHANGE", "REMOVE"}
+	if got := changedKeys(old, updated); !slices.Equal(got, want) {
+		t.Errorf("changedKeys() = %v, want %v", got, want)
+	}
+	if got := changedKeys(old, old); len(got) != 0 {
+		t.Errorf("changedKeys() of equal maps = %v, want none", got)
+	}
+}
+
+func testWatchOptions() runOptions {
+	return runOptions{
+		watch:         true,
+		watchInterval: 20 * time.Millisecond,
+		restartSignal: syscall.SIGTERM,
+		gracePeriod:   time.Second,
+	}
+}
+
+// startScripts returns a start func that runs the scripts in turn, one per
+// start, recording the secrets each one was started with
+func startScripts(t *testing.T, scripts ...string) (func(map[string]string) (*process.Child, error), *[]map[string]string) {
+	var started []map[string]string
+	start := func(secrets map[string]string) (*process.Child, error) {
+		if len(started) == len(scripts) {
+			t.Fatalf("unexpected start #%d", len(started)+1)
+		}
+		script := scripts[len(started)]
+		started = append(started, secrets)
+		return process.Start(exec.Command("sh", "-c", script))
+	}
+	return start, &started
+}
+
+func TestWatchAndRestartOnChange(t *testing.T) {
+	start, started := startScripts(t, "exec sleep 10", "exit 4")
+	load := func() (map[string]string, error) {
+		return map[string]string{"A": "2"}, nil
+	}
+
+	err := watchAndRestart(nil, map[string]string{"A": "1"}, load, start, testWatchOptions())
+
+	// The restarted command's status is returned
+	var exitErr *process.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 4 {
+		t.Errorf("watchAndRestart() = %v, want exit code 4", err)
+	}
+	if len(*started) != 2 {
+		t.Fatalf("Expected 2 starts, got %d", len(*started))
+	}
+	if (*started)[0]["A"] != "1" || (*started)[1]["A"] != "2" {
+		t.Errorf("Expected starts with A=1 then A=2, got %v", *started)
+	}
+}
+
+func TestWatchAndRestartLoadError(t *testing.T) {
+	start, started := startScripts(t, "sleep 0.2; exit 5")
+	loads := 0
+	load := func() (map[string]string, error) {
+		loads++
+		return nil, errors.New("vault unavailable")
+	}
+
+	err := watchAndRestart(nil, map[string]string{"A": "1"}, load, start, testWatchOptions())
+
+	// A failing check keeps the running command
+	var exitErr *process.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 5 {
+		t.Errorf("watchAndRestart() = %v, want exit code 5", err)
+	}
+	if len(*started) != 1 {
+		t.Errorf("Expected 1 start, got %d", len(*started))
+	}
+	if loads == 0 {
+		t.Error("Expected the secrets to be checked")
+	}
+}
+
+func TestWatchAndRestartExitStatus(t *testing.T) {
+	start, started := startScripts(t, "sleep 0.1")
+	load := func() (map[string]string, error) {
+		return map[string]string{"A": "1"}, nil
+	}
+
+	// Unchanged secrets don't restart; the command's own exit ends the watch
+	if err := watchAndRestart(nil, map[string]string{"A": "1"}, load, start, testWatchOptions()); err != nil {
+		t.Errorf("watchAndRestart() = %v, want nil", err)
+	}
+	if len(*started) != 1 {
+		t.Errorf("Expected 1 start, got %d", len(*started))
+	}
+}
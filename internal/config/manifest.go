@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// ManifestFile is the project manifest committed to an application repository
+const ManifestFile = ".nvolt.toml"
+
+// Manifest pins the nvolt settings of a project (.nvolt.toml)
+type Manifest struct {
+	Project      string   `toml:"project"`      // Project name in the vault
+	Vault        string   `toml:"vault"`        // Global vault (org/repo)
+	Environment  string   `toml:"environment"`  // Default environment
+	Environments []string `toml:"environments"` // Allowed environments (empty: any)
+	Compose      []string `toml:"compose"`      // Projects loaded below this one
+
+	Secrets map[string]SecretSpec `toml:"secrets"` // Schema of the project's secrets
+
+	Path string `toml:"-"` // Where the manifest was found
+}
+
+// FindManifest looks for .nvolt.toml in dir and its parents.
+// Returns nil if there is none.
+func FindManifest(dir string) (*Manifest, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		path := filepath.Join(dir, ManifestFile)
+		if _, err := os.Stat(path); err == nil {
+			return LoadManifest(path)
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, nil
+		}
+		dir = parent
+	}
+}
+
+// LoadManifest reads and validates a project manifest
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s not found", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	m := &Manifest{}
+	decoder := toml.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(m); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	m.Path = path
+
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+
+	return m, nil
+}
+
+// Validate checks the values of a manifest
+func (m *Manifest) Validate() error {
+	if m.Project != "" && sanitizeProjectName(m.Project) != m.Project {
+		return fmt.Errorf("project '%s' must be lowercase letters, digits, '-' or '_'", m.Project)
+	}
+
+	if m.Vault != "" && strings.Count(m.Vault, "/") != 1 {
+		return fmt.Errorf("vault must be org/repo, got '%s'", m.Vault)
+	}
+
+	if m.Environment != "" && !m.AllowsEnvironment(m.Environment) {
+		return fmt.Errorf("environment '%s' is not in environments", m.Environment)
+	}
+
+	for _, project := range m.Compose {
+		if project == "" {
+			return fmt.Errorf("compose contains an empty project")
+		}
+	}
+
+	for key, spec := range m.Secrets {
+		if err := spec.validate(); err != nil {
+			return fmt.Errorf("secrets.%s: %w", key, err)
+		}
+		for _, env := range append(slices.Clone(spec.Environments), spec.RequiredIn...) {
+			if !m.AllowsEnvironment(env) {
+				return fmt.Errorf("secrets.%s: environment '%s' is not in environments", key, env)
+			}
+		}
+	}
+
+	return nil
+}
+
+// AllowsEnvironment reports whether env may be used with this project
+func (m *Manifest) AllowsEnvironment(env string) bool {
+	return len(m.Environments) == 0 || slices.Contains(m.Environments, env)
+}
+
+// CheckEnvironment returns an error if env is not allowed by the manifest
+func (m *Manifest) CheckEnvironment(env string) error {
+	if m.AllowsEnvironment(env) {
+		return nil
+	}
+	return fmt.Errorf("environment '%s' is not allowed by %s (allowed: %s)",
+		env, m.Path, strings.Join(m.Environments, ", "))
+}
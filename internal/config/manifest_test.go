@@ -0,0 +1,103 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeManifest(t *testing.T, dir, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, ManifestFile), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+}
+
+func TestFindManifestWalksUp(t *testing.T) {
+	root := t.TempDir()
+	writeManifest(t, root, `project = "api"
+vault = "acme/secrets"
+environment = "dev"
+environments = ["dev", "prod"]
+compose = ["shared", "acme/infra:db"]
+`)
+
+	sub := filepath.Join(root, "services", "api")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+
+	m, err := FindManifest(sub)
+	if err != nil {
+		t.Fatalf("FindManifest failed: %v", err)
+	}
+	if m == nil {
+		t.Fatal("FindManifest should find the manifest in a parent directory")
+	}
+
+	if m.Project != "api" || m.Vault != "acme/secrets" || m.Environment != "dev" {
+		t.Errorf("Unexpected manifest: %+v", m)
+	}
+	if !reflect.DeepEqual(m.Compose, []string{"shared", "acme/infra:db"}) {
+		t.Errorf("Compose = %v", m.Compose)
+	}
+	if m.Path != filepath.Join(root, ManifestFile) {
+		t.Errorf("Path = %s", m.Path)
+	}
+
+	if err := m.CheckEnvironment("prod"); err != nil {
+		t.Errorf("prod should be allowed: %v", err)
+	}
+	if err := m.CheckEnvironment("staging"); err == nil {
+		t.Error("staging should not be allowed")
+	}
+}
+
+func TestFindManifestNone(t *testing.T) {
+	m, err := FindManifest(t.TempDir())
+	if err != nil || m != nil {
+		t.Errorf("FindManifest = %v, %v; want nil, nil", m, err)
+	}
+}
+
+func TestLoadManifestValidation(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":      `projct = "api"`,
+		"invalid project":    `project = "My API"`,
+		"invalid vault":      `vault = "secrets"`,
+		"disallowed default": "environment = \"qa\"\nenvironments = [\"dev\"]",
+		"empty compose":      `compose = [""]`,
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeManifest(t, dir, content)
+			if _, err := LoadManifest(filepath.Join(dir, ManifestFile)); err == nil {
+				t.Error("LoadManifest should fail")
+			}
+		})
+	}
+}
+
+func TestGetProjectNameFromManifest(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"name": "detected"}`), 0644); err != nil {
+		t.Fatalf("Failed to create package.json: %v", err)
+	}
+	writeManifest(t, dir, `project = "pinned"`)
+
+	name, source, err := GetProjectName(dir, "")
+	if err != nil {
+		t.Fatalf("GetProjectName failed: %v", err)
+	}
+	if name != "pinned" || source != ManifestFile {
+		t.Errorf("GetProjectName = %s (%s), want pinned (%s)", name, source, ManifestFile)
+	}
+
+	// An override still wins
+	if name, _, _ := GetProjectName(dir, "other"); name != "other" {
+		t.Errorf("Override should win over the manifest, got %s", name)
+	}
+}
@@ -206,12 +206,21 @@ func sanitizeProjectName(name string) string {
 	return name
 }
 
-// GetProjectName gets the project name, using override if provided
+// GetProjectName gets the project name, using override if provided.
+// A .nvolt.toml manifest takes precedence over detection.
 func GetProjectName(basePath, override string) (string, string, error) {
 	if override != "" {
 		return sanitizeProjectName(override), "override", nil
 	}
 
+	manifest, err := FindManifest(basePath)
+	if err != nil {
+		return "", "", err
+	}
+	if manifest != nil && manifest.Project != "" {
+		return manifest.Project, ManifestFile, nil
+	}
+
 	info, err := DetectProjectName(basePath)
 	if err != nil {
 		return "", "", err
@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Secret types of a schema
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeBool   = "bool"
+	TypeURL    = "url"
+	TypePort   = "port"
+	TypeRegex  = "regex"
+	TypeEnum   = "enum"
+)
+
+// SecretTypes lists the supported secret types
+var SecretTypes = []string{TypeString, TypeInt, TypeBool, TypeURL, TypePort, TypeRegex, TypeEnum}
+
+// SecretSpec declares a secret in the [secrets] table of .nvolt.toml
+type SecretSpec struct {
+	Type         string   `toml:"type"`         // Defaults to string
+	Required     bool     `toml:"required"`     // Required in every environment it applies to
+	RequiredIn   []string `toml:"required_in"`  // Required only in these environments
+	Environments []string `toml:"environments"` // Environments the key applies to (empty: all)
+	Pattern      string   `toml:"pattern"`      // Full-match pattern of a regex secret
+	Values       []string `toml:"values"`       // Allowed values of an enum secret
+	Description  string   `toml:"description"`
+}
+
+// SchemaViolation is a secret that does not match the schema
+type SchemaViolation struct {
+	Key     string
+	Message string
+}
+
+func (v SchemaViolation) Error() string {
+	return fmt.Sprintf("%s: %s", v.Key, v.Message)
+}
+
+// validate checks the declaration of a secret
+func (s SecretSpec) validate() error {
+	if s.Type != "" && !slices.Contains(SecretTypes, s.Type) {
+		return fmt.Errorf("unknown type '%s' (expected %s)", s.Type, strings.Join(SecretTypes, ", "))
+	}
+
+	switch s.Type {
+	case TypeRegex:
+		if s.Pattern == "" {
+			return fmt.Errorf("regex type requires a pattern")
+		}
+		if _, err := regexp.Compile(s.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	case TypeEnum:
+		if len(s.Values) == 0 {
+			return fmt.Errorf("enum type requires values")
+		}
+	}
+
+	return nil
+}
+
+// AppliesTo reports whether the secret is declared for env
+func (s SecretSpec) AppliesTo(env string) bool {
+	return len(s.Environments) == 0 || slices.Contains(s.Environments, env)
+}
+
+// IsRequired reports whether the secret must be set in env
+func (s SecretSpec) IsRequired(env string) bool {
+	return s.AppliesTo(env) && (s.Required || slices.Contains(s.RequiredIn, env))
+}
+
+// ValidateValue checks a value against the type of the secret
+func (s SecretSpec) ValidateValue(value string) error {
+	switch s.Type {
+	case TypeInt:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("expected an integer")
+		}
+	case TypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("expected true or false")
+		}
+	case TypeURL:
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("expected a URL with a scheme and host")
+		}
+	case TypePort:
+		port, err := strconv.Atoi(value)
+		if err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("expected a port between 1 and 65535")
+		}
+	case TypeRegex:
+		re, err := regexp.Compile("^(?:" + s.Pattern + ")$")
+		if err != nil || !re.MatchString(value) {
+			return fmt.Errorf("does not match pattern %s", s.Pattern)
+		}
+	case TypeEnum:
+		if !slices.Contains(s.Values, value) {
+			return fmt.Errorf("expected one of %s", strings.Join(s.Values, ", "))
+		}
+	}
+
+	return nil
+}
+
+// HasSchema reports whether the manifest declares secrets
+func (m *Manifest) HasSchema() bool {
+	return m != nil && len(m.Secrets) > 0
+}
+
+// ValidateSecrets checks the secrets of env against the schema, sorted by key.
+// With partial, missing required secrets are not reported (e.g. on push).
+func (m *Manifest) ValidateSecrets(env string, secrets map[string]string, partial bool) []SchemaViolation {
+	var violations []SchemaViolation
+
+	for key, spec := range m.Secrets {
+		if !spec.AppliesTo(env) {
+			continue
+		}
+
+		value, ok := secrets[key]
+		if !ok {
+			if !partial && spec.IsRequired(env) {
+				violations = append(violations, SchemaViolation{Key: key, Message: "required but not set"})
+			}
+			continue
+		}
+
+		if err := spec.ValidateValue(value); err != nil {
+			violations = append(violations, SchemaViolation{Key: key, Message: err.Error()})
+		}
+	}
+
+	sort.Slice(violations, func(i, j int) bool {
+		return violations[i].Key < violations[j].Key
+	})
+
+	return violations
+}
+
+// UndeclaredSecrets returns the keys of secrets missing from the schema, sorted
+func (m *Manifest) UndeclaredSecrets(secrets map[string]string) []string {
+	var keys []string
+	for key := range secrets {
+		if _, ok := m.Secrets[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSecretSpecValidateValue(t *testing.T) {
+	tests := []struct {
+		spec  SecretSpec
+		valid []string
+		bad   []string
+	}{
+		{SecretSpec{}, []string{"", "anything"}, nil},
+		{SecretSpec{Type: TypeInt}, []string{"42", "-1"}, []string{"4.2", "x"}},
+		{SecretSpec{Type: TypeBool}, []string{"true", "0"}, []string{"yes"}},
+		{SecretSpec{Type: TypeURL}, []string{"https://example.com", "redis://:pw@cache:6379/0"}, []string{"example.com", "not a url"}},
+		{SecretSpec{Type: TypePort}, []string{"1", "65535"}, []string{"0", "65536", "http"}},
+		{SecretSpec{Type: TypeRegex, Pattern: "sk_[a-z0-9]+"}, []string{"sk_abc123"}, []string{"xsk_abc", "sk_ABC"}},
+		{SecretSpec{Type: TypeEnum, Values: []string{"debug", "info"}}, []string{"info"}, []string{"warn"}},
+	}
+
+	for _, tt := range tests {
+		for _, value := range tt.valid {
+			if err := tt.spec.ValidateValue(value); err != nil {
+				t.Errorf("%s: %q should be valid: %v", tt.spec.Type, value, err)
+			}
+		}
+		for _, value := range tt.bad {
+			if err := tt.spec.ValidateValue(value); err == nil {
+				t.Errorf("%s: %q should be invalid", tt.spec.Type, value)
+			}
+		}
+	}
+}
+
+func TestManifestValidateSecrets(t *testing.T) {
+	dir := t.TempDir()
+	writeManifest(t, dir, `environments = ["dev", "prod"]
+
+[secrets.DATABASE_URL]
+type = "url"
+required = true
+
+[secrets.PORT]
+type = "port"
+
+[secrets.SENTRY_DSN]
+required_in = ["prod"]
+
+[secrets.DEBUG_TOKEN]
+environments = ["dev"]
+required = true
+`)
+
+	m, err := LoadManifest(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		t.Fatalf("LoadManifest failed: %v", err)
+	}
+
+	secrets := map[string]string{"DATABASE_URL": "localhost", "PORT": "8080", "EXTRA": "x"}
+
+	keys := func(violations []SchemaViolation) []string {
+		var keys []string
+		for _, v := range violations {
+			keys = append(keys, v.Key)
+		}
+		return keys
+	}
+
+	if got := keys(m.ValidateSecrets("prod", secrets, false)); !reflect.DeepEqual(got, []string{"DATABASE_URL", "SENTRY_DSN"}) {
+		t.Errorf("prod violations = %v", got)
+	}
+	if got := keys(m.ValidateSecrets("dev", secrets, false)); !reflect.DeepEqual(got, []string{"DATABASE_URL", "DEBUG_TOKEN"}) {
+		t.Errorf("dev violations = %v", got)
+	}
+
+	// Partial checks skip missing secrets
+	if got := keys(m.ValidateSecrets("prod", secrets, true)); !reflect.DeepEqual(got, []string{"DATABASE_URL"}) {
+		t.Errorf("partial violations = %v", got)
+	}
+
+	if got := m.UndeclaredSecrets(secrets); !reflect.DeepEqual(got, []string{"EXTRA"}) {
+		t.Errorf("UndeclaredSecrets = %v", got)
+	}
+}
+
+func TestManifestSchemaDeclarationErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown type":      "[secrets.A]\ntype = \"float\"",
+		"regex without":     "[secrets.A]\ntype = \"regex\"",
+		"invalid regex":     "[secrets.A]\ntype = \"regex\"\npattern = \"(\"",
+		"enum without":      "[secrets.A]\ntype = \"enum\"",
+		"unknown env":       "environments = [\"dev\"]\n[secrets.A]\nrequired_in = [\"prod\"]",
+		"unknown attribute": "[secrets.A]\nrequird = true",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeManifest(t, dir, content)
+			if _, err := LoadManifest(filepath.Join(dir, ManifestFile)); err == nil {
+				t.Error("LoadManifest should fail")
+			}
+		})
+	}
+}
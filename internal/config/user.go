@@ -0,0 +1,256 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// UserConfigFile is the user-level configuration in the nvolt home directory
+const UserConfigFile = "config.toml"
+
+// Sources of a resolved setting
+const (
+	SourceDefault = "default"
+	SourceFile    = UserConfigFile
+)
+
+// UserConfig holds user-level defaults and policies (~/.nvolt/config.toml)
+type UserConfig struct {
+	Defaults DefaultsConfig `toml:"defaults,omitempty"`
+	Policy   PolicyConfig   `toml:"policy,omitempty"`
+	Git      GitConfig      `toml:"git,omitempty"`
+	UI       UIConfig       `toml:"ui,omitempty"`
+}
+
+// DefaultsConfig holds default flag values
+type DefaultsConfig struct {
+	Environment string `toml:"environment,omitempty"`
+	Vault       string `toml:"vault,omitempty"`
+	Format      string `toml:"format,omitempty"`
+}
+
+// PolicyConfig holds access policies
+type PolicyConfig struct {
+	AutoGrant *bool `toml:"auto_grant,omitempty"`
+}
+
+// GitConfig controls git operations on global vaults
+type GitConfig struct {
+	Pull *bool `toml:"pull,omitempty"`
+	Push *bool `toml:"push,omitempty"`
+}
+
+// UIConfig controls terminal output
+type UIConfig struct {
+	Color string `toml:"color,omitempty"`
+}
+
+// UserConfigKey describes a setting of the user config
+type UserConfigKey struct {
+	Name        string // Dotted name, e.g. defaults.environment
+	EnvVar      string // Environment variable overriding the file
+	Default     string
+	Description string
+
+	get func(c *UserConfig) string // "" if not set
+	set func(c *UserConfig, value string) error
+}
+
+// UserConfigKeys lists every supported setting
+var UserConfigKeys = []UserConfigKey{
+	{
+		Name: "defaults.environment", EnvVar: "NVOLT_ENV", Default: "default",
+		Description: "Environment used when -e is not given",
+		get:         func(c *UserConfig) string { return c.Defaults.Environment },
+		set:         func(c *UserConfig, v string) error { c.Defaults.Environment = v; return nil },
+	},
+	{
+		Name: "defaults.vault", EnvVar: "NVOLT_VAULT",
+		Description: "Global vault (org/repo) used when several are cloned",
+		get:         func(c *UserConfig) string { return c.Defaults.Vault },
+		set: func(c *UserConfig, v string) error {
+			if v != "" && strings.Count(v, "/") != 1 {
+				return fmt.Errorf("expected org/repo, got '%s'", v)
+			}
+			c.Defaults.Vault = v
+			return nil
+		},
+	},
+	{
+		Name: "defaults.format", EnvVar: "NVOLT_FORMAT",
+		Description: "Output format used when --format is not given",
+		get:         func(c *UserConfig) string { return c.Defaults.Format },
+		set:         func(c *UserConfig, v string) error { c.Defaults.Format = v; return nil },
+	},
+	{
+		Name: "policy.auto_grant", EnvVar: "NVOLT_AUTO_GRANT", Default: "false",
+		Description: "Grant new machines access during sync without prompting",
+		get:         func(c *UserConfig) string { return formatBool(c.Policy.AutoGrant) },
+		set:         func(c *UserConfig, v string) error { return parseBool(v, &c.Policy.AutoGrant) },
+	},
+	{
+		Name: "git.pull", EnvVar: "NVOLT_GIT_PULL", Default: "true",
+		Description: "Pull global vaults before reading or writing",
+		get:         func(c *UserConfig) string { return formatBool(c.Git.Pull) },
+		set:         func(c *UserConfig, v string) error { return parseBool(v, &c.Git.Pull) },
+	},
+	{
+		Name: "git.push", EnvVar: "NVOLT_GIT_PUSH", Default: "true",
+		Description: "Push global vault commits (false: commit only)",
+		get:         func(c *UserConfig) string { return formatBool(c.Git.Push) },
+		set:         func(c *UserConfig, v string) error { return parseBool(v, &c.Git.Push) },
+	},
+	{
+		Name: "ui.color", EnvVar: "NVOLT_COLOR", Default: "auto",
+		Description: "Colored output: auto, always or never",
+		get:         func(c *UserConfig) string { return c.UI.Color },
+		set: func(c *UserConfig, v string) error {
+			switch v {
+			case "", "auto", "always", "never":
+				c.UI.Color = v
+				return nil
+			}
+			return fmt.Errorf("expected auto, always or never, got '%s'", v)
+		},
+	},
+}
+
+// LookupUserConfigKey returns the setting with the given name
+func LookupUserConfigKey(name string) (*UserConfigKey, error) {
+	for i := range UserConfigKeys {
+		if UserConfigKeys[i].Name == name {
+			return &UserConfigKeys[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown setting '%s'", name)
+}
+
+// LoadUserConfig reads a user config. A missing file is an empty config.
+func LoadUserConfig(path string) (*UserConfig, error) {
+	cfg := &UserConfig{}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if err := toml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	// Validate values edited by hand
+	for _, key := range UserConfigKeys {
+		if err := key.set(cfg, key.get(cfg)); err != nil {
+			return nil, fmt.Errorf("invalid %s in %s: %w", key.Name, path, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// SaveUserConfig writes a user config
+func SaveUserConfig(path string, cfg *UserConfig) error {
+	data, err := toml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal user config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Get returns the value of a setting in the file ("" if not set)
+func (c *UserConfig) Get(name string) (string, error) {
+	key, err := LookupUserConfigKey(name)
+	if err != nil {
+		return "", err
+	}
+	return key.get(c), nil
+}
+
+// Set changes a setting; an empty value removes it from the file
+func (c *UserConfig) Set(name, value string) error {
+	key, err := LookupUserConfigKey(name)
+	if err != nil {
+		return err
+	}
+	if err := key.set(c, value); err != nil {
+		return fmt.Errorf("invalid value for %s: %w", name, err)
+	}
+	return nil
+}
+
+// Resolve returns the effective value of a setting and where it came from:
+// the environment variable, then the file, then the built-in default
+func (c *UserConfig) Resolve(name string) (string, string) {
+	key, err := LookupUserConfigKey(name)
+	if err != nil {
+		return "", ""
+	}
+
+	if key.EnvVar != "" {
+		if value := os.Getenv(key.EnvVar); value != "" {
+			return value, key.EnvVar
+		}
+	}
+
+	if value := key.get(c); value != "" {
+		return value, SourceFile
+	}
+
+	return key.Default, SourceDefault
+}
+
+// ResolveBool resolves a boolean setting; invalid environment values fall
+// back to the built-in default
+func (c *UserConfig) ResolveBool(name string) bool {
+	value, _ := c.Resolve(name)
+	if enabled, err := strconv.ParseBool(value); err == nil {
+		return enabled
+	}
+
+	key, err := LookupUserConfigKey(name)
+	if err != nil {
+		return false
+	}
+	enabled, _ := strconv.ParseBool(key.Default)
+	return enabled
+}
+
+func formatBool(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b)
+}
+
+func parseBool(value string, target **bool) error {
+	if value == "" {
+		*target = nil
+		return nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("expected true or false, got '%s'", value)
+	}
+	*target = &b
+	return nil
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUserConfigRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "home", UserConfigFile)
+
+	cfg, err := LoadUserConfig(path)
+	if err != nil {
+		t.Fatalf("Loading a missing config should succeed: %v", err)
+	}
+
+	settings := map[string]string{
+		"defaults.environment": "production",
+		"defaults.vault":       "acme/secrets",
+		"policy.auto_grant":    "true",
+		"git.push":             "false",
+		"ui.color":             "never",
+	}
+	for name, value := range settings {
+		if err := cfg.Set(name, value); err != nil {
+			t.Fatalf("Set(%s) failed: %v", name, err)
+		}
+	}
+
+	if err := SaveUserConfig(path, cfg); err != nil {
+		t.Fatalf("SaveUserConfig failed: %v", err)
+	}
+
+	loaded, err := LoadUserConfig(path)
+	if err != nil {
+		t.Fatalf("LoadUserConfig failed: %v", err)
+	}
+
+	for name, want := range settings {
+		if got, _ := loaded.Get(name); got != want {
+			t.Errorf("Get(%s) = %q, want %q", name, got, want)
+		}
+	}
+	if got, _ := loaded.Get("git.pull"); got != "" {
+		t.Errorf("Unset setting should be empty, got %q", got)
+	}
+
+	// Unsetting every setting leaves an empty file
+	for name := range settings {
+		if err := loaded.Set(name, ""); err != nil {
+			t.Fatalf("Set(%s, \"\") failed: %v", name, err)
+		}
+	}
+	if err := SaveUserConfig(path, loaded); err != nil {
+		t.Fatalf("SaveUserConfig failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if strings.TrimSpace(string(data)) != "" {
+		t.Errorf("Empty config should write an empty file, got:\n%s", data)
+	}
+}
+
+func TestUserConfigValidation(t *testing.T) {
+	cfg := &UserConfig{}
+
+	invalid := map[string]string{
+		"defaults.vault":    "not-a-spec",
+		"policy.auto_grant": "maybe",
+		"ui.color":          "rainbow",
+	}
+	for name, value := range invalid {
+		if err := cfg.Set(name, value); err == nil {
+			t.Errorf("Set(%s, %q) should fail", name, value)
+		}
+	}
+
+	if err := cfg.Set("unknown.key", "x"); err == nil {
+		t.Error("Unknown settings should be rejected")
+	}
+
+	// Hand-edited files are validated on load
+	path := filepath.Join(t.TempDir(), UserConfigFile)
+	if err := os.WriteFile(path, []byte("[ui]\ncolor = \"rainbow\"\n"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := LoadUserConfig(path); err == nil {
+		t.Error("LoadUserConfig should reject invalid values")
+	}
+}
+
+func TestUserConfigResolve(t *testing.T) {
+	t.Setenv("NVOLT_ENV", "")
+	t.Setenv("NVOLT_GIT_PUSH", "")
+
+	cfg := &UserConfig{}
+
+	if value, source := cfg.Resolve("defaults.environment"); value != "default" || source != SourceDefault {
+		t.Errorf("Resolve = %q, %q; want built-in default", value, source)
+	}
+
+	cfg.Set("defaults.environment", "staging")
+	if value, source := cfg.Resolve("defaults.environment"); value != "staging" || source != SourceFile {
+		t.Errorf("Resolve = %q, %q; want file value", value, source)
+	}
+
+	t.Setenv("NVOLT_ENV", "production")
+	if value, source := cfg.Resolve("defaults.environment"); value != "production" || source != "NVOLT_ENV" {
+		t.Errorf("Resolve = %q, %q; want environment value", value, source)
+	}
+
+	if !cfg.ResolveBool("git.push") {
+		t.Error("git.push should default to true")
+	}
+	cfg.Set("git.push", "false")
+	if cfg.ResolveBool("git.push") {
+		t.Error("git.push should be false when set in the file")
+	}
+	t.Setenv("NVOLT_GIT_PUSH", "invalid")
+	if !cfg.ResolveBool("git.push") {
+		t.Error("Invalid environment values should fall back to the built-in default")
+	}
+}
@@ -0,0 +1,150 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+)
+
+// Generator types of GenerateSecret
+const (
+	GenPassword   = "password"
+	GenHex        = "hex"
+	GenBase64     = "base64"
+	GenUUID       = "uuid"
+	GenRSAKey     = "rsa-key"
+	GenEd25519Key = "ed25519-key"
+)
+
+// GeneratorTypes lists the supported generator types
+var GeneratorTypes = []string{GenPassword, GenHex, GenBase64, GenUUID, GenRSAKey, GenEd25519Key}
+
+// Charsets are the named character sets for passwords; symbols adds
+// shell-safe punctuation to letters and digits
+var Charsets = map[string]string{
+	"alnum":   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	"alpha":   "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+	"digits":  "0123456789",
+	"symbols": "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!#%+-.:=?@^_~",
+}
+
+// Default lengths: characters for passwords, random bytes for hex and
+// base64, bits for RSA keys
+const (
+	DefaultPasswordLength = 32
+	DefaultRandomBytes    = 32
+	DefaultCharset        = "symbols"
+)
+
+// GenerateSecret creates a random secret value with crypto/rand.
+// length is ignored for uuid and ed25519-key; 0 selects the default.
+// charset is a named set (alnum, alpha, digits, symbols) or the literal
+// characters to use, and only applies to passwords.
+func GenerateSecret(kind string, length int, charset string) (string, error) {
+	if length < 0 {
+		return "", fmt.Errorf("length must be positive")
+	}
+
+	switch kind {
+	case GenPassword:
+		if length == 0 {
+			length = DefaultPasswordLength
+		}
+		return generatePassword(length, charset)
+
+	case GenHex, GenBase64:
+		if length == 0 {
+			length = DefaultRandomBytes
+		}
+		buf := make([]byte, length)
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("failed to read random bytes: %w", err)
+		}
+		defer ZeroBytes(buf)
+		if kind == GenHex {
+			return hex.EncodeToString(buf), nil
+		}
+		return base64.StdEncoding.EncodeToString(buf), nil
+
+	case GenUUID:
+		return generateUUID()
+
+	case GenRSAKey:
+		if length == 0 {
+			length = RSAKeySize
+		}
+		if length < 2048 {
+			return "", fmt.Errorf("RSA keys need at least 2048 bits")
+		}
+		key, err := rsa.GenerateKey(rand.Reader, length)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate RSA key: %w", err)
+		}
+		return encodePKCS8(key)
+
+	case GenEd25519Key:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate Ed25519 key: %w", err)
+		}
+		return encodePKCS8(key)
+	}
+
+	return "", fmt.Errorf("unknown generator type '%s'", kind)
+}
+
+// generatePassword picks length characters uniformly from charset
+func generatePassword(length int, charset string) (string, error) {
+	if charset == "" {
+		charset = DefaultCharset
+	}
+	if named, ok := Charsets[charset]; ok {
+		charset = named
+	}
+
+	chars := []rune(charset)
+	if len(chars) < 2 {
+		return "", fmt.Errorf("charset needs at least 2 characters")
+	}
+
+	max := big.NewInt(int64(len(chars)))
+	password := make([]rune, length)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to read random bytes: %w", err)
+		}
+		password[i] = chars[n.Int64()]
+	}
+
+	return string(password), nil
+}
+
+// generateUUID returns a random (version 4) UUID
+func generateUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // Version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// encodePKCS8 encodes a private key as a PKCS#8 PEM block
+func encodePKCS8(key any) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal private key: %w", err)
+	}
+	defer ZeroBytes(der)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
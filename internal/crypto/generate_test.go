@@ -0,0 +1,102 @@
+package crypto
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGenerateSecretPassword(t *testing.T) {
+	password, err := GenerateSecret(GenPassword, 0, "")
+	if err != nil {
+		t.Fatalf("GenerateSecret failed: %v", err)
+	}
+	if len(password) != DefaultPasswordLength {
+		t.Errorf("Expected %d characters, got %d", DefaultPasswordLength, len(password))
+	}
+
+	digits, err := GenerateSecret(GenPassword, 64, "digits")
+	if err != nil {
+		t.Fatalf("GenerateSecret failed: %v", err)
+	}
+	if !regexp.MustCompile(`^[0-9]{64}$`).MatchString(digits) {
+		t.Errorf("Expected 64 digits, got %q", digits)
+	}
+
+	literal, err := GenerateSecret(GenPassword, 20, "ab")
+	if err != nil {
+		t.Fatalf("GenerateSecret failed: %v", err)
+	}
+	if strings.Trim(literal, "ab") != "" || len(literal) != 20 {
+		t.Errorf("Expected 20 characters from 'ab', got %q", literal)
+	}
+
+	if _, err := GenerateSecret(GenPassword, 10, "a"); err == nil {
+		t.Error("A single-character charset should be rejected")
+	}
+
+	other, _ := GenerateSecret(GenPassword, 0, "")
+	if password == other {
+		t.Error("Two generated passwords should not be identical")
+	}
+}
+
+func TestGenerateSecretEncodings(t *testing.T) {
+	hexValue, err := GenerateSecret(GenHex, 16, "")
+	if err != nil {
+		t.Fatalf("GenerateSecret failed: %v", err)
+	}
+	if b, err := hex.DecodeString(hexValue); err != nil || len(b) != 16 {
+		t.Errorf("Expected 16 hex-encoded bytes, got %q", hexValue)
+	}
+
+	b64Value, err := GenerateSecret(GenBase64, 0, "")
+	if err != nil {
+		t.Fatalf("GenerateSecret failed: %v", err)
+	}
+	if b, err := base64.StdEncoding.DecodeString(b64Value); err != nil || len(b) != DefaultRandomBytes {
+		t.Errorf("Expected %d base64-encoded bytes, got %q", DefaultRandomBytes, b64Value)
+	}
+
+	uuid, err := GenerateSecret(GenUUID, 0, "")
+	if err != nil {
+		t.Fatalf("GenerateSecret failed: %v", err)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(uuid) {
+		t.Errorf("Invalid UUID %q", uuid)
+	}
+
+	if _, err := GenerateSecret("rot13", 0, ""); err == nil {
+		t.Error("Unknown types should be rejected")
+	}
+}
+
+func TestGenerateSecretKeys(t *testing.T) {
+	for _, kind := range []string{GenEd25519Key, GenRSAKey} {
+		length := 0
+		if kind == GenRSAKey {
+			length = 2048 // Keep the test fast
+		}
+
+		value, err := GenerateSecret(kind, length, "")
+		if err != nil {
+			t.Fatalf("GenerateSecret(%s) failed: %v", kind, err)
+		}
+
+		block, _ := pem.Decode([]byte(value))
+		if block == nil || block.Type != "PRIVATE KEY" {
+			t.Fatalf("%s: expected a PRIVATE KEY PEM block", kind)
+		}
+		if _, err := x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
+			t.Errorf("%s: invalid PKCS#8 key: %v", kind, err)
+		}
+	}
+
+	if _, err := GenerateSecret(GenRSAKey, 1024, ""); err == nil {
+		t.Error("Weak RSA keys should be rejected")
+	}
+}
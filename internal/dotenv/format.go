@@ -0,0 +1,77 @@
+package dotenv
+
+import (
+	"io"
+	"sort"
+	"strings"
+)
+
+// Format renders secrets as a dotenv file with keys in sorted order
+func Format(secrets map[string]string) string {
+	keys := make([]string, 0, len(secrets))
+	for key := range secrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(Quote(secrets[key]))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Write writes secrets to w in the format of Format
+func Write(w io.Writer, secrets map[string]string) error {
+	_, err := io.WriteString(w, Format(secrets))
+	return err
+}
+
+// Quote returns value in a form that parses back to the same value. Plain
+// values are left bare, values without single quotes or control characters
+// are single-quoted, anything else is double-quoted with escapes.
+func Quote(value string) string {
+	if isBare(value) {
+		return value
+	}
+
+	if !strings.ContainsAny(value, "'\n\r\t") {
+		return "'" + value + "'"
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '"', '\\', '$':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// isBare reports whether value can be written without quotes
+func isBare(value string) bool {
+	for _, r := range value {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("_-.,:/@%+=~^", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,295 @@
+// Package dotenv reads and writes .env files.
+//
+// The accepted syntax follows common dotenv conventions:
+//
+//	# comment
+//	export KEY=value          # inline comment after whitespace
+//	KEY = 'literal, no escapes, may span lines'
+//	KEY="escapes \n \t \" \\ \$, may span lines"
+//
+// Values are never expanded; ${...} references are left as written.
+package dotenv
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Entry is a KEY=value assignment of a dotenv file
+type Entry struct {
+	Key   string
+	Value string
+	Line  int // Line of the key, starting at 1
+}
+
+// SyntaxError reports a malformed dotenv file
+type SyntaxError struct {
+	File   string // Empty when parsing a reader
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+	}
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// ParseFile parses a dotenv file
+func ParseFile(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	entries, err := ParseString(string(data))
+	if syntaxErr, ok := err.(*SyntaxError); ok {
+		syntaxErr.File = path
+	}
+	return entries, err
+}
+
+// Parse parses dotenv content from r
+func Parse(r io.Reader) ([]Entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dotenv content: %w", err)
+	}
+	return ParseString(string(data))
+}
+
+// ParseString parses dotenv content. Entries are returned in file order;
+// a key may appear more than once.
+func ParseString(content string) ([]Entry, error) {
+	p := &parser{src: strings.ReplaceAll(content, "\r\n", "\n"), line: 1, col: 1}
+
+	var entries []Entry
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return entries, nil
+		}
+
+		entry, err := p.entry()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// ToMap converts entries to a map; later entries override earlier ones
+func ToMap(entries []Entry) map[string]string {
+	values := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		values[entry.Key] = entry.Value
+	}
+	return values
+}
+
+type parser struct {
+	src  string
+	pos  int
+	line int
+	col  int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) next() byte {
+	c := p.src[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+		p.col = 1
+	} else {
+		p.col++
+	}
+	return c
+}
+
+func (p *parser) errorf(line, col int, format string, args ...any) error {
+	return &SyntaxError{Line: line, Column: col, Msg: fmt.Sprintf(format, args...)}
+}
+
+// skipSpaces skips spaces and tabs on the current line
+func (p *parser) skipSpaces() {
+	for c := p.peek(); c == ' ' || c == '\t'; c = p.peek() {
+		p.next()
+	}
+}
+
+// skipBlank skips blank lines and comment lines
+func (p *parser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\n':
+			p.next()
+		case '#':
+			p.skipLine()
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) skipLine() {
+	for !p.eof() && p.peek() != '\n' {
+		p.next()
+	}
+}
+
+// endOfLine accepts trailing spaces and an optional comment after a value
+func (p *parser) endOfLine() error {
+	p.skipSpaces()
+	switch p.peek() {
+	case 0, '\n':
+		return nil
+	case '#':
+		p.skipLine()
+		return nil
+	}
+	return p.errorf(p.line, p.col, "unexpected character %q after quoted value", p.peek())
+}
+
+func (p *parser) entry() (Entry, error) {
+	line := p.line
+	key := p.word()
+
+	// export KEY=value
+	if key == "export" && (p.peek() == ' ' || p.peek() == '\t') {
+		p.skipSpaces()
+		line = p.line
+		key = p.word()
+	}
+
+	if key == "" {
+		return Entry{}, p.errorf(p.line, p.col, "expected a key, found %q", p.peek())
+	}
+
+	p.skipSpaces()
+	if p.peek() != '=' {
+		if p.eof() || p.peek() == '\n' {
+			return Entry{}, p.errorf(p.line, p.col, "expected '=' after key %s", key)
+		}
+		return Entry{}, p.errorf(p.line, p.col, "unexpected character %q in key %s", p.peek(), key)
+	}
+	p.next()
+	p.skipSpaces()
+
+	value, err := p.value()
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return Entry{Key: key, Value: value, Line: line}, nil
+}
+
+// word reads a key: letters, digits, '_', '.' and '-'
+func (p *parser) word() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c == '_' || c == '.' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') {
+			p.next()
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *parser) value() (string, error) {
+	switch p.peek() {
+	case '\'':
+		return p.singleQuoted()
+	case '"':
+		return p.doubleQuoted()
+	}
+	return p.unquoted(), nil
+}
+
+// unquoted reads to the end of the line or an inline comment (" #")
+func (p *parser) unquoted() string {
+	var b strings.Builder
+	for !p.eof() && p.peek() != '\n' {
+		c := p.next()
+		if (c == ' ' || c == '\t') && p.peek() == '#' {
+			p.skipLine()
+			break
+		}
+		b.WriteByte(c)
+	}
+	return strings.TrimRight(b.String(), " \t")
+}
+
+// singleQuoted reads a literal value up to the closing quote
+func (p *parser) singleQuoted() (string, error) {
+	line, col := p.line, p.col
+	p.next()
+
+	start := p.pos
+	for !p.eof() && p.peek() != '\'' {
+		p.next()
+	}
+	if p.eof() {
+		return "", p.errorf(line, col, "unterminated single-quoted value")
+	}
+	value := p.src[start:p.pos]
+	p.next()
+
+	return value, p.endOfLine()
+}
+
+// doubleQuoted reads a value with backslash escapes up to the closing quote
+func (p *parser) doubleQuoted() (string, error) {
+	line, col := p.line, p.col
+	p.next()
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf(line, col, "unterminated double-quoted value")
+		}
+
+		c := p.next()
+		switch c {
+		case '"':
+			return b.String(), p.endOfLine()
+		case '\\':
+			if p.eof() {
+				continue
+			}
+			switch e := p.next(); e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\', '$', '\'':
+				b.WriteByte(e)
+			case '\n':
+				// Line continuation
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package formats
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/iluxav/nvolt/internal/dotenv"
+)
+
+// Output-only formats
+const (
+	ShellExport = "shell-export"
+	Systemd     = "systemd"
+	GitHubEnv   = "github-env"
+)
+
+// ExportFormats lists the formats accepted by Export
+var ExportFormats = []string{Env, JSON, YAML, ShellExport, DockerEnv, K8sSecret, Systemd, GitHubEnv, TFVars}
+
+// DefaultSecretName is the name of exported Kubernetes Secrets
+const DefaultSecretName = "nvolt-secrets"
+
+// ExportOptions control the metadata of formats that need it
+type ExportOptions struct {
+	// Name and Namespace of a Kubernetes Secret
+	Name      string
+	Namespace string
+}
+
+// Export renders secrets in the given format with keys in sorted order
+func Export(format string, secrets map[string]string, opts ExportOptions) (string, error) {
+	keys := make([]string, 0, len(secrets))
+	for key := range secrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	switch format {
+	case Env:
+		return dotenv.Format(secrets), nil
+
+	case JSON:
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if secrets == nil {
+			secrets = map[string]string{}
+		}
+		if err := encoder.Encode(secrets); err != nil {
+			return "", fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return buf.String(), nil
+
+	case YAML:
+		if len(keys) == 0 {
+			return "{}\n", nil
+		}
+		for _, key := range keys {
+			writeYAMLEntry(&b, "", key, secrets[key])
+		}
+
+	case ShellExport:
+		for _, key := range keys {
+			fmt.Fprintf(&b, "export %s=%s\n", key, shellQuote(secrets[key]))
+		}
+
+	case DockerEnv:
+		for _, key := range keys {
+			if strings.ContainsAny(secrets[key], "\r\n") {
+				return "", fmt.Errorf("%s: docker env files cannot contain multi-line values", key)
+			}
+			fmt.Fprintf(&b, "%s=%s\n", key, secrets[key])
+		}
+
+	case K8sSecret:
+		name := opts.Name
+		if name == "" {
+			name = DefaultSecretName
+		}
+		b.WriteString("apiVersion: v1\nkind: Secret\nmetadata:\n")
+		writeYAMLEntry(&b, "  ", "name", name)
+		if opts.Namespace != "" {
+			writeYAMLEntry(&b, "  ", "namespace", opts.Namespace)
+		}
+		b.WriteString("type: Opaque\n")
+		if len(keys) == 0 {
+			b.WriteString("data: {}\n")
+		} else {
+			b.WriteString("data:\n")
+			for _, key := range keys {
+				fmt.Fprintf(&b, "  %s: %s\n", key, base64.StdEncoding.EncodeToString([]byte(secrets[key])))
+			}
+		}
+
+	case Systemd:
+		for _, key := range keys {
+			fmt.Fprintf(&b, "%s=%s\n", key, systemdQuote(secrets[key]))
+		}
+
+	case GitHubEnv:
+		for _, key := range keys {
+			value := secrets[key]
+			if !strings.ContainsAny(value, "\r\n") {
+				fmt.Fprintf(&b, "%s=%s\n", key, value)
+				continue
+			}
+			// Multi-line values use a random delimiter that cannot occur in
+			// the value, so a value cannot inject further variables
+			delimiter, err := githubDelimiter(value)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, "%s<<%s\n%s\n%s\n", key, delimiter, value, delimiter)
+		}
+
+	case TFVars:
+		for _, key := range keys {
+			fmt.Fprintf(&b, "%s = %s\n", key, hclQuote(secrets[key]))
+		}
+
+	default:
+		return "", fmt.Errorf("unknown format '%s' (supported: %s)", format, strings.Join(ExportFormats, ", "))
+	}
+
+	return b.String(), nil
+}
+
+var (
+	shellSafeRegex = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+	yamlPlainRegex = regexp.MustCompile(`^[A-Za-z_/][A-Za-z0-9_./@+-]*$`)
+)
+
+// shellQuote quotes a value for POSIX shells
+func shellQuote(value string) string {
+	if shellSafeRegex.MatchString(value) {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// systemdQuote quotes a value for systemd EnvironmentFile=, where double
+// quotes allow values with spaces and newlines and backslash escapes
+// quotes, backslashes, backticks and dollar signs
+func systemdQuote(value string) string {
+	if shellSafeRegex.MatchString(value) {
+		return value
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", `$`, `\$`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// hclQuote quotes a value as an HCL string; template sequences are escaped
+// so values are never interpolated
+func hclQuote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "${", "$${", "%{", "%%{")
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// yamlKeywords are plain scalars that YAML parsers resolve to non-strings
+var yamlKeywords = map[string]bool{
+	"true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+	"y": true, "n": true, "null": true, "~": true,
+}
+
+// writeYAMLEntry writes "key: value", using a plain scalar when the value
+// reads back as the same string, a literal block for multi-line values and
+// a double-quoted scalar otherwise
+func writeYAMLEntry(b *strings.Builder, indent, key, value string) {
+	if yamlKeywords[strings.ToLower(key)] {
+		quotedKey, _ := json.Marshal(key)
+		key = string(quotedKey)
+	}
+	fmt.Fprintf(b, "%s%s:", indent, key)
+
+	switch {
+	case yamlPlainRegex.MatchString(value) && !yamlKeywords[strings.ToLower(value)]:
+		fmt.Fprintf(b, " %s\n", value)
+		return
+	case isYAMLBlock(value):
+		body := value
+		indicator := "|-"
+		if strings.HasSuffix(value, "\n") {
+			body = value[:len(value)-1]
+			indicator = "|"
+			if strings.HasSuffix(body, "\n") {
+				indicator = "|+"
+			}
+		}
+		fmt.Fprintf(b, " %s\n", indicator)
+		for _, line := range strings.Split(body, "\n") {
+			if line != "" {
+				b.WriteString(indent + "  " + line)
+			}
+			b.WriteByte('\n')
+		}
+		return
+	}
+
+	// JSON strings are valid YAML double-quoted scalars
+	quoted, _ := json.Marshal(value)
+	fmt.Fprintf(b, " %s\n", quoted)
+}
+
+// isYAMLBlock reports whether a value can be written as a literal block
+func isYAMLBlock(value string) bool {
+	if !strings.Contains(value, "\n") || strings.HasPrefix(value, " ") || strings.HasPrefix(value, "\n") {
+		return false
+	}
+	for _, line := range strings.Split(value, "\n") {
+		if strings.HasSuffix(line, " ") || strings.HasSuffix(line, "\t") {
+			return false
+		}
+		for _, r := range line {
+			if r < 0x20 && r != '\t' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// githubDelimiter returns a random heredoc delimiter not contained in value
+func githubDelimiter(value string) (string, error) {
+	for {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("failed to read random bytes: %w", err)
+		}
+		delimiter := "NVOLT_EOF_" + hex.EncodeToString(buf)
+		if !strings.Contains(value, delimiter) {
+			return delimiter, nil
+		}
+	}
+}
@@ -0,0 +1,281 @@
+// Package formats converts secrets from and to the file formats used by
+// other tools: JSON, YAML, Kubernetes Secrets, Docker env files, Java
+// properties and Terraform variables.
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iluxav/nvolt/internal/dotenv"
+	"github.com/iluxav/nvolt/internal/validation"
+)
+
+// Supported formats
+const (
+	Env        = "env"
+	JSON       = "json"
+	YAML       = "yaml"
+	K8sSecret  = "k8s-secret"
+	DockerEnv  = "docker-env"
+	Properties = "properties"
+	TFVars     = "tfvars"
+)
+
+// ImportFormats lists the formats accepted by Import
+var ImportFormats = []string{Env, JSON, YAML, K8sSecret, DockerEnv, Properties, TFVars}
+
+// DefaultSeparator joins the keys of nested structures
+const DefaultSeparator = "_"
+
+// ImportOptions control how imported keys are turned into secret keys
+type ImportOptions struct {
+	// Separator joins nested keys, e.g. {"db": {"host": ...}} becomes DB_HOST
+	Separator string
+	// KeepCase keeps the case of keys instead of upper-casing them
+	KeepCase bool
+}
+
+var separatorRegex = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// Import decodes data in the given format into flat secrets
+func Import(format string, data []byte, opts ImportOptions) (map[string]string, error) {
+	if opts.Separator == "" {
+		opts.Separator = DefaultSeparator
+	}
+	if !separatorRegex.MatchString(opts.Separator) {
+		return nil, fmt.Errorf("invalid separator '%s': only letters, digits and underscores are allowed", opts.Separator)
+	}
+
+	f := &flattener{opts: opts, secrets: make(map[string]string), sources: make(map[string]string)}
+
+	switch format {
+	case Env:
+		entries, err := dotenv.ParseString(string(data))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			f.set([]string{entry.Key}, entry.Value)
+		}
+
+	case DockerEnv:
+		values, err := parseDockerEnv(string(data))
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range values {
+			f.set([]string{kv[0]}, kv[1])
+		}
+
+	case JSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		if err := f.root(value); err != nil {
+			return nil, err
+		}
+
+	case YAML:
+		docs, err := parseYAML(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			if err := f.root(doc); err != nil {
+				return nil, err
+			}
+		}
+
+	case K8sSecret:
+		values, err := parseK8sSecret(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range values {
+			f.set([]string{kv[0]}, kv[1])
+		}
+
+	case Properties:
+		values, err := parseProperties(string(data))
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range values {
+			f.set(strings.Split(kv[0], "."), kv[1])
+		}
+
+	case TFVars:
+		value, err := parseTFVars(string(data))
+		if err != nil {
+			return nil, err
+		}
+		if err := f.root(value); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown format '%s' (supported: %s)", format, strings.Join(ImportFormats, ", "))
+	}
+
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.secrets, nil
+}
+
+var k8sSecretRegex = regexp.MustCompile(`(?m)^kind:\s*["']?Secret["']?\s*$|"kind"\s*:\s*"Secret"`)
+
+// DetectFormat guesses the format of a file from its name and content
+func DetectFormat(path string, data []byte) (string, error) {
+	name := strings.ToLower(filepath.Base(path))
+
+	switch {
+	case strings.HasSuffix(name, ".yaml"), strings.HasSuffix(name, ".yml"):
+		if k8sSecretRegex.Match(data) {
+			return K8sSecret, nil
+		}
+		return YAML, nil
+	case strings.HasSuffix(name, ".json"):
+		if k8sSecretRegex.Match(data) {
+			return K8sSecret, nil
+		}
+		return JSON, nil
+	case strings.HasSuffix(name, ".properties"):
+		return Properties, nil
+	case strings.HasSuffix(name, ".tfvars"):
+		return TFVars, nil
+	case name == ".env", strings.HasPrefix(name, ".env."), strings.HasSuffix(name, ".env"):
+		return Env, nil
+	}
+
+	return "", fmt.Errorf("cannot detect the format of %s, use --format (%s)", path, strings.Join(ImportFormats, ", "))
+}
+
+// flattener turns nested values into secret keys and reports collisions
+type flattener struct {
+	opts    ImportOptions
+	secrets map[string]string
+	sources map[string]string // Secret key -> original path
+	err     error
+}
+
+// root flattens a document, which must be a mapping
+func (f *flattener) root(value any) error {
+	switch value.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		f.flatten(nil, value)
+		return nil
+	}
+	return fmt.Errorf("expected a mapping of keys to values at the top level")
+}
+
+func (f *flattener) flatten(path []string, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			f.flatten(append(path[:len(path):len(path)], key), v[key])
+		}
+	case []any:
+		for i, item := range v {
+			f.flatten(append(path[:len(path):len(path)], strconv.Itoa(i)), item)
+		}
+	case nil:
+		f.set(path, "")
+	case string:
+		f.set(path, v)
+	case json.Number:
+		f.set(path, v.String())
+	case bool:
+		f.set(path, strconv.FormatBool(v))
+	default:
+		f.set(path, fmt.Sprint(v))
+	}
+}
+
+// set stores a value under the secret key derived from path
+func (f *flattener) set(path []string, value string) {
+	if f.err != nil {
+		return
+	}
+
+	source := strings.Join(path, ".")
+	key := f.key(path)
+	if err := validation.ValidateSecretKey(key); err != nil {
+		f.err = fmt.Errorf("cannot import '%s': %w", source, err)
+		return
+	}
+	if previous, ok := f.sources[key]; ok && previous != source {
+		f.err = fmt.Errorf("'%s' and '%s' both map to secret key %s", previous, source, key)
+		return
+	}
+
+	f.sources[key] = source
+	f.secrets[key] = value
+}
+
+// key joins path with the separator and replaces characters that are not
+// allowed in secret keys with underscores
+func (f *flattener) key(path []string) string {
+	parts := make([]string, len(path))
+	for i, part := range path {
+		parts[i] = strings.Map(func(r rune) rune {
+			if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+				return r
+			}
+			return '_'
+		}, part)
+	}
+
+	key := strings.Join(parts, f.opts.Separator)
+	if key != "" && key[0] >= '0' && key[0] <= '9' {
+		key = "_" + key
+	}
+	if !f.opts.KeepCase {
+		key = strings.ToUpper(key)
+	}
+	return key
+}
+
+// parseDockerEnv parses a file for 'docker run --env-file': values are
+// taken literally and a bare KEY takes its value from the environment
+func parseDockerEnv(content string) ([][2]string, error) {
+	var values [][2]string
+	for i, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, hasValue := strings.Cut(line, "=")
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: invalid variable name '%s'", i+1, key)
+		}
+		if !hasValue {
+			env, ok := os.LookupEnv(key)
+			if !ok {
+				continue
+			}
+			value = env
+		}
+		values = append(values, [2]string{key, value})
+	}
+	return values, nil
+}
@@ -0,0 +1,390 @@
+package formats
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImportJSON(t *testing.T) {
+	data := `{
+  "database": {"host": "db.internal", "port": 5432, "ssl": true},
+  "api-key": "abc",
+  "hosts": ["a", "b"],
+  "empty": null
+}`
+
+	got, err := Import(JSON, []byte(data), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	want := map[string]string{
+		"DATABASE_HOST": "db.internal",
+		"DATABASE_PORT": "5432",
+		"DATABASE_SSL":  "true",
+		"API_KEY":       "abc",
+		"HOSTS_0":       "a",
+		"HOSTS_1":       "b",
+		"EMPTY":         "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Import() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestImportOptions(t *testing.T) {
+	data := `{"db": {"user": "admin"}}`
+
+	got, err := Import(JSON, []byte(data), ImportOptions{Separator: "__", KeepCase: true})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if want := map[string]string{"db__user": "admin"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Import() = %v, want %v", got, want)
+	}
+
+	if _, err := Import(JSON, []byte(data), ImportOptions{Separator: "."}); err == nil {
+		t.Error("Import should reject a separator that is not valid in keys")
+	}
+}
+
+func TestImportCollision(t *testing.T) {
+	_, err := Import(JSON, []byte(`{"db": {"host": "a"}, "db_host": "b"}`), ImportOptions{})
+	if err == nil || !strings.Contains(err.Error(), "DB_HOST") {
+		t.Errorf("Import error = %v, want collision on DB_HOST", err)
+	}
+}
+
+func TestImportYAML(t *testing.T) {
+	data := `# Application settings
+database:
+  host: db.internal   # primary
+  password: "p@ss: \"word\""
+  replicas:
+  - host: r1
+    port: 5433
+  - host: r2
+tags: [web, 'api']
+labels: {team: core, tier: "1"}
+certificate: |
+  -----BEGIN CERTIFICATE-----
+  MIIB
+  -----END CERTIFICATE-----
+description: >-
+  folded
+  text
+
+  paragraph
+nothing: ~
+---
+second: doc
+`
+
+	got, err := Import(YAML, []byte(data), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	want := map[string]string{
+		"DATABASE_HOST":            "db.internal",
+		"DATABASE_PASSWORD":        `p@ss: "word"`,
+		"DATABASE_REPLICAS_0_HOST": "r1",
+		"DATABASE_REPLICAS_0_PORT": "5433",
+		"DATABASE_REPLICAS_1_HOST": "r2",
+		"TAGS_0":                   "web",
+		"TAGS_1":                   "api",
+		"LABELS_TEAM":              "core",
+		"LABELS_TIER":              "1",
+		"CERTIFICATE":              "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n",
+		"DESCRIPTION":              "folded text\nparagraph",
+		"NOTHING":                  "",
+		"SECOND":                   "doc",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Import() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestImportYAMLErrors(t *testing.T) {
+	tests := map[string]string{
+		"a: 1\n  b: 2\n":       "line 2",
+		"a: 1\na: 2\n":         "duplicate key",
+		"a: \"open\n":          "end of stream",
+		"a: *missing\n":        "unknown anchor",
+		"a: &x [*x]\n":         "contains itself",
+		"- a\n- b\n":           "top level",
+		"a:\n\tb: 1\n":         "line 2",
+		"a: [1, 2\n":           "line 1",
+		"[a]: 1\n":             "scalar mapping keys",
+		"key: value\nbroken\n": "line 2",
+	}
+
+	for data, want := range tests {
+		_, err := Import(YAML, []byte(data), ImportOptions{})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Import(%q) error = %v, want %q", data, err, want)
+		}
+	}
+}
+
+func TestImportYAMLAnchors(t *testing.T) {
+	data := `defaults: &defaults
+  host: db.internal
+  port: 0777
+production:
+  <<: *defaults
+  port: 5432
+  password: !secret hunter2
+  enabled: yes
+hosts:
+- &primary r1
+- *primary
+`
+
+	got, err := Import(YAML, []byte(data), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	want := map[string]string{
+		"DEFAULTS_HOST":       "db.internal",
+		"DEFAULTS_PORT":       "0777",
+		"PRODUCTION_HOST":     "db.internal",
+		"PRODUCTION_PORT":     "5432",
+		"PRODUCTION_PASSWORD": "hunter2",
+		"PRODUCTION_ENABLED":  "yes",
+		"HOSTS_0":             "r1",
+		"HOSTS_1":             "r1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Import() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestImportK8sSecret(t *testing.T) {
+	data := `apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+type: Opaque
+data:
+  db-password: c2VjcmV0
+  tls.crt: LS0tLS1CRUdJTgpkYXRhCg==
+stringData:
+  api_key: plain
+`
+
+	got, err := Import(K8sSecret, []byte(data), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	want := map[string]string{
+		"DB_PASSWORD": "secret",
+		"TLS_CRT":     "-----BEGIN\ndata\n",
+		"API_KEY":     "plain",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Import() = %v, want %v", got, want)
+	}
+
+	if _, err := Import(K8sSecret, []byte("kind: Secret\ndata:\n  a: '!!!'\n"), ImportOptions{}); err == nil || !strings.Contains(err.Error(), "base64") {
+		t.Errorf("Import error = %v, want base64 error", err)
+	}
+	if _, err := Import(K8sSecret, []byte("kind: ConfigMap\n"), ImportOptions{}); err == nil {
+		t.Error("Import should fail without a Secret")
+	}
+
+	jsonData := `{"kind": "Secret", "data": {"token": "dG9r"}}`
+	if got, err := Import(K8sSecret, []byte(jsonData), ImportOptions{}); err != nil || got["TOKEN"] != "tok" {
+		t.Errorf("Import(JSON) = %v, %v, want TOKEN=tok", got, err)
+	}
+}
+
+func TestImportDockerEnv(t *testing.T) {
+	t.Setenv("FROM_HOST", "host value")
+	data := "# comment\nA=\"quoted\" stays\nB=x=y\nFROM_HOST\nUNSET_IN_HOST_ENV\n"
+
+	got, err := Import(DockerEnv, []byte(data), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	want := map[string]string{"A": `"quoted" stays`, "B": "x=y", "FROM_HOST": "host value"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Import() = %v, want %v", got, want)
+	}
+}
+
+func TestImportProperties(t *testing.T) {
+	data := `# comment
+! also a comment
+db.url=jdbc:postgresql://localhost/app
+db.user : admin
+greeting Hello \
+    World
+escaped\ key=tab\there \u00e9
+empty=
+`
+
+	got, err := Import(Properties, []byte(data), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	want := map[string]string{
+		"DB_URL":      "jdbc:postgresql://localhost/app",
+		"DB_USER":     "admin",
+		"GREETING":    "Hello World",
+		"ESCAPED_KEY": "tab\there é",
+		"EMPTY":       "",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Import() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestImportTFVars(t *testing.T) {
+	data := `# Terraform variables
+region = "eu-west-1" // inline comment
+instance_count = 3
+enabled = true
+/* block
+   comment */
+tags = {
+  team = "core"
+  "cost-center": "42",
+}
+zones = ["a", "b",
+  "c"]
+policy = <<-EOT
+    {
+      "Version": "2012"
+    }
+  EOT
+template = "$${literal}"
+`
+
+	got, err := Import(TFVars, []byte(data), ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	want := map[string]string{
+		"REGION":           "eu-west-1",
+		"INSTANCE_COUNT":   "3",
+		"ENABLED":          "true",
+		"TAGS_TEAM":        "core",
+		"TAGS_COST_CENTER": "42",
+		"ZONES_0":          "a",
+		"ZONES_1":          "b",
+		"ZONES_2":          "c",
+		"POLICY":           "{\n  \"Version\": \"2012\"\n}\n",
+		"TEMPLATE":         "${literal}",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Import() =\n%#v\nwant\n%#v", got, want)
+	}
+
+	if _, err := Import(TFVars, []byte("a = var.other\n"), ImportOptions{}); err == nil {
+		t.Error("Import should reject expressions")
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		path string
+		data string
+		want string
+	}{
+		{"config.json", `{"a": 1}`, JSON},
+		{"values.yaml", "a: 1\n", YAML},
+		{"secret.yml", "apiVersion: v1\nkind: Secret\n", K8sSecret},
+		{"app.properties", "", Properties},
+		{"prod.tfvars", "", TFVars},
+		{".env.production", "", Env},
+	}
+
+	for _, tt := range tests {
+		got, err := DetectFormat(tt.path, []byte(tt.data))
+		if err != nil || got != tt.want {
+			t.Errorf("DetectFormat(%s) = %s, %v, want %s", tt.path, got, err, tt.want)
+		}
+	}
+
+	if _, err := DetectFormat("secrets.txt", nil); err == nil {
+		t.Error("DetectFormat should fail for unknown extensions")
+	}
+}
+
+var exportSecrets = map[string]string{
+	"API_KEY":  "abc123",
+	"PEM":      "-----BEGIN KEY-----\nMIIB\n-----END KEY-----\n",
+	"QUOTES":   `it's "quoted" \ $HOME ${REF} %{x}`,
+	"NUMBER":   "5432",
+	"BOOL":     "yes",
+	"EMPTY":    "",
+	"SPACES":   "  padded  ",
+	"NEWLINES": "a\n\nb\n\n",
+	"UNICODE":  "héllo: wörld # not a comment",
+	"Y":        "n",
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	for _, format := range []string{Env, JSON, YAML, K8sSecret, TFVars} {
+		output, err := Export(format, exportSecrets, ExportOptions{})
+		if err != nil {
+			t.Errorf("Export(%s) failed: %v", format, err)
+			continue
+		}
+
+		got, err := Import(format, []byte(output), ImportOptions{KeepCase: true})
+		if err != nil {
+			t.Errorf("Import(Export(%s)) failed: %v\n%s", format, err, output)
+			continue
+		}
+		if !reflect.DeepEqual(got, exportSecrets) {
+			t.Errorf("%s round trip =\n%#v\nwant\n%#v\noutput:\n%s", format, got, exportSecrets, output)
+		}
+
+		again, _ := Export(format, exportSecrets, ExportOptions{})
+		if again != output {
+			t.Errorf("Export(%s) is not deterministic", format)
+		}
+	}
+}
+
+func TestExportFormats(t *testing.T) {
+	secrets := map[string]string{"B": "it's $x", "A": "plain", "M": "line1\nline2"}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{ShellExport, []string{"export A=plain\n", `export B='it'\''s $x'` + "\n", "export M='line1\nline2'\n"}},
+		{Systemd, []string{"A=plain\n", `B="it's \$x"` + "\n", "M=\"line1\nline2\"\n"}},
+		{GitHubEnv, []string{"A=plain\nB=it's $x\nM<<NVOLT_EOF_", "\nline1\nline2\nNVOLT_EOF_"}},
+		{K8sSecret, []string{"kind: Secret\n", "  name: app\n", "  namespace: prod\n", "  A: cGxhaW4=\n"}},
+	}
+
+	for _, tt := range tests {
+		output, err := Export(tt.format, secrets, ExportOptions{Name: "app", Namespace: "prod"})
+		if err != nil {
+			t.Errorf("Export(%s) failed: %v", tt.format, err)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(output, want) {
+				t.Errorf("Export(%s) =\n%s\nwant it to contain %q", tt.format, output, want)
+			}
+		}
+	}
+
+	if _, err := Export(DockerEnv, secrets, ExportOptions{}); err == nil {
+		t.Error("Export(docker-env) should reject multi-line values")
+	}
+	if _, err := Export("xml", secrets, ExportOptions{}); err == nil {
+		t.Error("Export should reject unknown formats")
+	}
+}
@@ -0,0 +1,120 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// parseK8sSecret extracts the data of the Kubernetes Secrets in a YAML or
+// JSON manifest. data values are base64-decoded; stringData values override
+// them like they do in the API server.
+func parseK8sSecret(data []byte) ([][2]string, error) {
+	var docs []any
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var doc any
+		if err := json.Unmarshal(trimmed, &doc); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		docs = []any{doc}
+	} else {
+		var err error
+		if docs, err = parseYAML(data); err != nil {
+			return nil, err
+		}
+	}
+
+	var secrets []map[string]any
+	for _, doc := range docs {
+		obj, ok := doc.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch obj["kind"] {
+		case "Secret":
+			secrets = append(secrets, obj)
+		case "List":
+			items, _ := obj["items"].([]any)
+			for _, item := range items {
+				if itemObj, ok := item.(map[string]any); ok && itemObj["kind"] == "Secret" {
+					secrets = append(secrets, itemObj)
+				}
+			}
+		}
+	}
+	if len(secrets) == 0 {
+		return nil, fmt.Errorf("no Kubernetes Secret (kind: Secret) found")
+	}
+
+	var values [][2]string
+	for _, secret := range secrets {
+		name := secretName(secret)
+
+		encoded, err := stringMap(secret["data"], name, "data")
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range sortedKeys(encoded) {
+			decoded, err := base64.StdEncoding.DecodeString(encoded[key])
+			if err != nil {
+				return nil, fmt.Errorf("secret %s: data.%s is not valid base64: %w", name, key, err)
+			}
+			values = append(values, [2]string{key, string(decoded)})
+		}
+
+		plain, err := stringMap(secret["stringData"], name, "stringData")
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range sortedKeys(plain) {
+			values = append(values, [2]string{key, plain[key]})
+		}
+	}
+
+	return values, nil
+}
+
+// secretName returns metadata.name of a Secret for error messages
+func secretName(secret map[string]any) string {
+	if metadata, ok := secret["metadata"].(map[string]any); ok {
+		if name, ok := metadata["name"].(string); ok && name != "" {
+			return name
+		}
+	}
+	return "(unnamed)"
+}
+
+// stringMap checks that a data or stringData field maps keys to strings
+func stringMap(value any, name, field string) (map[string]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	obj, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("secret %s: %s must be a mapping", name, field)
+	}
+
+	result := make(map[string]string, len(obj))
+	for key, v := range obj {
+		switch s := v.(type) {
+		case string:
+			result[key] = s
+		case nil:
+			result[key] = ""
+		default:
+			return nil, fmt.Errorf("secret %s: %s.%s must be a string", name, field, key)
+		}
+	}
+	return result, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
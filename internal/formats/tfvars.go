@@ -0,0 +1,366 @@
+package formats
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTFVars parses a Terraform .tfvars file: "name = value" attributes
+// whose values are strings, heredocs, numbers, booleans, null, lists and
+// objects. Expressions and function calls are not supported.
+func parseTFVars(content string) (map[string]any, error) {
+	tokens, err := tokenizeHCL(strings.ReplaceAll(content, "\r\n", "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &hclParser{tokens: tokens}
+	values := map[string]any{}
+	for {
+		p.skipNewlines()
+		tok := p.next()
+		if tok.kind == hclEOF {
+			return values, nil
+		}
+		if tok.kind != hclIdent {
+			return nil, p.unexpected(tok, "a variable name")
+		}
+		if _, exists := values[tok.text]; exists {
+			return nil, fmt.Errorf("line %d: duplicate variable '%s'", tok.line, tok.text)
+		}
+
+		if eq := p.next(); eq.kind != hclPunct || eq.text != "=" {
+			return nil, p.unexpected(eq, "'='")
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values[tok.text] = value
+
+		if end := p.next(); end.kind != hclNewline && end.kind != hclEOF {
+			return nil, p.unexpected(end, "a new line")
+		}
+	}
+}
+
+type hclKind int
+
+const (
+	hclEOF hclKind = iota
+	hclNewline
+	hclIdent
+	hclNumber
+	hclString
+	hclPunct
+)
+
+type hclToken struct {
+	kind hclKind
+	text string
+	line int
+}
+
+func tokenizeHCL(src string) ([]hclToken, error) {
+	var tokens []hclToken
+	line := 1
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			tokens = append(tokens, hclToken{hclNewline, "", line})
+			line++
+			i++
+
+		case c == ' ' || c == '\t':
+			i++
+
+		case c == '#' || strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+
+		case strings.HasPrefix(src[i:], "<<"):
+			value, consumed, lines, err := readHeredoc(src[i:], line)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, hclToken{hclString, value, line})
+			line += lines
+			i += consumed
+
+		case c == '"':
+			value, consumed, err := readHCLString(src[i:], line)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, hclToken{hclString, value, line})
+			i += consumed
+
+		case strings.IndexByte("={}[],:", c) >= 0:
+			tokens = append(tokens, hclToken{hclPunct, string(c), line})
+			i++
+
+		case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			start := i
+			for i++; i < len(src) && strings.IndexByte("0123456789.eE+-", src[i]) >= 0; i++ {
+			}
+			tokens = append(tokens, hclToken{hclNumber, src[start:i], line})
+
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] == '-' || (src[i] >= 'a' && src[i] <= 'z') || (src[i] >= 'A' && src[i] <= 'Z') || (src[i] >= '0' && src[i] <= '9')) {
+				i++
+			}
+			tokens = append(tokens, hclToken{hclIdent, src[start:i], line})
+
+		default:
+			return nil, fmt.Errorf("line %d: unexpected character %q (expressions are not supported)", line, c)
+		}
+	}
+
+	return append(tokens, hclToken{hclEOF, "", line}), nil
+}
+
+// readHCLString reads a double-quoted string; ${ and %{ sequences are kept
+// as written since tfvars files cannot reference variables
+func readHCLString(src string, line int) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(src); i++ {
+		switch c := src[i]; c {
+		case '"':
+			return b.String(), i + 1, nil
+		case '\n':
+			return "", 0, fmt.Errorf("line %d: unterminated string", line)
+		case '\\':
+			if i+1 >= len(src) {
+				return "", 0, fmt.Errorf("line %d: unterminated string", line)
+			}
+			i++
+			switch e := src[i]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\':
+				b.WriteByte(e)
+			case 'u', 'U':
+				size := 4
+				if e == 'U' {
+					size = 8
+				}
+				if i+size >= len(src) {
+					return "", 0, fmt.Errorf("line %d: invalid escape sequence '\\%c'", line, e)
+				}
+				code, err := strconv.ParseUint(src[i+1:i+1+size], 16, 32)
+				if err != nil {
+					return "", 0, fmt.Errorf("line %d: invalid escape sequence '\\%s'", line, src[i:i+1+size])
+				}
+				b.WriteRune(rune(code))
+				i += size
+			default:
+				return "", 0, fmt.Errorf("line %d: invalid escape sequence '\\%c'", line, e)
+			}
+		case '$', '%':
+			// $${ and %%{ escape template sequences
+			if strings.HasPrefix(src[i+1:], string(c)+"{") {
+				i++
+			}
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("line %d: unterminated string", line)
+}
+
+// readHeredoc reads a <<MARKER or <<-MARKER heredoc and returns its value,
+// the number of bytes consumed and the number of lines spanned
+func readHeredoc(src string, line int) (string, int, int, error) {
+	header, rest, ok := strings.Cut(src, "\n")
+	if !ok {
+		return "", 0, 0, fmt.Errorf("line %d: unterminated heredoc", line)
+	}
+
+	marker := strings.TrimSpace(strings.TrimPrefix(header, "<<"))
+	indented := strings.HasPrefix(marker, "-")
+	marker = strings.TrimPrefix(marker, "-")
+	if marker == "" || strings.ContainsAny(marker, " \t\"") {
+		return "", 0, 0, fmt.Errorf("line %d: invalid heredoc marker", line)
+	}
+
+	lines := strings.SplitAfter(rest, "\n")
+	for n, l := range lines {
+		if strings.TrimSpace(l) != marker {
+			continue
+		}
+
+		body := lines[:n]
+		if indented {
+			body = trimCommonIndent(body)
+		}
+
+		consumed := len(header) + 1
+		for _, l := range lines[:n] {
+			consumed += len(l)
+		}
+		consumed += len(strings.TrimRight(l, "\n"))
+		return strings.Join(body, ""), consumed, n + 1, nil
+	}
+
+	return "", 0, 0, fmt.Errorf("line %d: heredoc is missing its closing %s", line, marker)
+}
+
+// trimCommonIndent removes the leading whitespace shared by all non-blank lines
+func trimCommonIndent(lines []string) []string {
+	common := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		indent := len(l) - len(strings.TrimLeft(l, " \t"))
+		if common < 0 || indent < common {
+			common = indent
+		}
+	}
+
+	trimmed := make([]string, len(lines))
+	for i, l := range lines {
+		if len(l) >= common && common > 0 && strings.TrimSpace(l[:common]) == "" {
+			l = l[common:]
+		}
+		trimmed[i] = l
+	}
+	return trimmed
+}
+
+type hclParser struct {
+	tokens []hclToken
+	pos    int
+}
+
+func (p *hclParser) next() hclToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != hclEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *hclParser) peek() hclToken {
+	return p.tokens[p.pos]
+}
+
+func (p *hclParser) skipNewlines() {
+	for p.peek().kind == hclNewline {
+		p.pos++
+	}
+}
+
+func (p *hclParser) unexpected(tok hclToken, expected string) error {
+	found := "'" + tok.text + "'"
+	switch tok.kind {
+	case hclEOF:
+		found = "end of file"
+	case hclNewline:
+		found = "end of line"
+	case hclString:
+		found = "a string"
+	}
+	return fmt.Errorf("line %d: expected %s, found %s", tok.line, expected, found)
+}
+
+func (p *hclParser) value() (any, error) {
+	tok := p.next()
+	switch tok.kind {
+	case hclString, hclNumber:
+		return tok.text, nil
+	case hclIdent:
+		switch tok.text {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	case hclPunct:
+		switch tok.text {
+		case "[":
+			return p.list()
+		case "{":
+			return p.object()
+		}
+	}
+	return nil, p.unexpected(tok, "a value")
+}
+
+func (p *hclParser) list() (any, error) {
+	items := []any{}
+	for {
+		p.skipNewlines()
+		if tok := p.peek(); tok.kind == hclPunct && tok.text == "]" {
+			p.next()
+			return items, nil
+		}
+
+		item, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		p.skipNewlines()
+		switch tok := p.next(); {
+		case tok.kind == hclPunct && tok.text == ",":
+		case tok.kind == hclPunct && tok.text == "]":
+			return items, nil
+		default:
+			return nil, p.unexpected(tok, "',' or ']'")
+		}
+	}
+}
+
+func (p *hclParser) object() (any, error) {
+	obj := map[string]any{}
+	for {
+		p.skipNewlines()
+		key := p.next()
+		if key.kind == hclPunct && key.text == "}" {
+			return obj, nil
+		}
+		if key.kind != hclIdent && key.kind != hclString {
+			return nil, p.unexpected(key, "a key")
+		}
+		if _, exists := obj[key.text]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key '%s'", key.line, key.text)
+		}
+
+		if sep := p.next(); sep.kind != hclPunct || (sep.text != "=" && sep.text != ":") {
+			return nil, p.unexpected(sep, "'=' or ':'")
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		obj[key.text] = value
+
+		if tok := p.peek(); tok.kind == hclPunct && tok.text == "," {
+			p.next()
+		} else if tok.kind != hclNewline && !(tok.kind == hclPunct && tok.text == "}") {
+			return nil, p.unexpected(tok, "',', '}' or a new line")
+		}
+	}
+}
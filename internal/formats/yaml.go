@@ -0,0 +1,164 @@
+package formats
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxYAMLNodes limits the size of a document once aliases are expanded, so
+// that nested aliases ("billion laughs") cannot exhaust memory
+const maxYAMLNodes = 1_000_000
+
+// parseYAML parses the documents of a YAML stream. Anchors, aliases, merge
+// keys (<<) and tags are supported. Scalars are returned as strings exactly
+// as written (0777 stays 0777, yes stays yes); null values as nil.
+func parseYAML(data []byte) ([]any, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	var docs []any
+	for {
+		var node yaml.Node
+		if err := decoder.Decode(&node); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return nil, err
+		}
+
+		c := &yamlConverter{}
+		doc, err := c.value(&node)
+		if err != nil {
+			return nil, err
+		}
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+}
+
+// yamlConverter turns YAML nodes into maps, slices and strings
+type yamlConverter struct {
+	nodes int
+	// expanding holds the aliased nodes being converted, to reject anchors
+	// that contain themselves
+	expanding []*yaml.Node
+}
+
+func (c *yamlConverter) value(node *yaml.Node) (any, error) {
+	if c.nodes++; c.nodes > maxYAMLNodes {
+		return nil, fmt.Errorf("line %d: document is too large once aliases are expanded", node.Line)
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return c.value(node.Content[0])
+
+	case yaml.MappingNode:
+		m := make(map[string]any, len(node.Content)/2)
+		if err := c.mapping(m, node); err != nil {
+			return nil, err
+		}
+		return m, nil
+
+	case yaml.SequenceNode:
+		items := make([]any, len(node.Content))
+		for i, item := range node.Content {
+			v, err := c.value(item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = v
+		}
+		return items, nil
+
+	case yaml.AliasNode:
+		for _, n := range c.expanding {
+			if n == node.Alias {
+				return nil, fmt.Errorf("line %d: anchor '%s' contains itself", node.Line, node.Value)
+			}
+		}
+		c.expanding = append(c.expanding, node.Alias)
+		defer func() { c.expanding = c.expanding[:len(c.expanding)-1] }()
+		return c.value(node.Alias)
+
+	case yaml.ScalarNode:
+		if node.ShortTag() == "!!null" {
+			return nil, nil
+		}
+		return node.Value, nil
+	}
+
+	return nil, fmt.Errorf("line %d: unexpected YAML node", node.Line)
+}
+
+// mapping adds the entries of a mapping node to m. Keys merged with << do
+// not override keys set explicitly.
+func (c *yamlConverter) mapping(m map[string]any, node *yaml.Node) error {
+	var merges []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+
+		if keyNode.Kind == yaml.ScalarNode && keyNode.ShortTag() == "!!merge" {
+			merges = append(merges, valueNode)
+			continue
+		}
+
+		key, err := c.value(keyNode)
+		if err != nil {
+			return err
+		}
+		name, ok := key.(string)
+		if !ok {
+			return fmt.Errorf("line %d: only scalar mapping keys are supported", keyNode.Line)
+		}
+		if _, exists := m[name]; exists {
+			return fmt.Errorf("line %d: duplicate key '%s'", keyNode.Line, name)
+		}
+
+		value, err := c.value(valueNode)
+		if err != nil {
+			return err
+		}
+		m[name] = value
+	}
+
+	for _, merge := range merges {
+		// << takes a mapping or a sequence of mappings, earlier ones winning
+		sources := []*yaml.Node{merge}
+		if resolveAlias(merge).Kind == yaml.SequenceNode {
+			sources = resolveAlias(merge).Content
+		}
+		for _, source := range sources {
+			value, err := c.value(source)
+			if err != nil {
+				return err
+			}
+			merged, ok := value.(map[string]any)
+			if !ok {
+				return fmt.Errorf("line %d: << must merge a mapping", source.Line)
+			}
+			for key, v := range merged {
+				if _, exists := m[key]; !exists {
+					m[key] = v
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveAlias returns the node an alias refers to
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
@@ -6,6 +6,26 @@ import (
 	"strings"
 )
 
+var (
+	pullEnabled = true
+	pushEnabled = true
+)
+
+// SetPullEnabled controls whether SafePull fetches remote changes
+func SetPullEnabled(enabled bool) {
+	pullEnabled = enabled
+}
+
+// SetPushEnabled controls whether CommitAndPush pushes after committing
+func SetPushEnabled(enabled bool) {
+	pushEnabled = enabled
+}
+
+// IsPushEnabled returns whether CommitAndPush pushes after committing
+func IsPushEnabled() bool {
+	return pushEnabled
+}
+
 // Clone clones a Git repository to the specified path
 func Clone(repoURL, targetPath string) error {
 	cmd := exec.Command("git", "clone", repoURL, targetPath)
@@ -134,6 +154,13 @@ func GetRepoPath(orgRepo string) (org string, repo string, err error) {
 		return "", "", fmt.Errorf("invalid repository format: org and repo cannot be empty")
 	}
 
+	// org and repo become directories under ~/.nvolt/orgs
+	for _, name := range []string{org, repo} {
+		if name == "." || name == ".." || strings.ContainsRune(name, '\\') {
+			return "", "", fmt.Errorf("invalid repository format: '%s'", orgRepo)
+		}
+	}
+
 	return org, repo, nil
 }
 
@@ -168,6 +195,10 @@ func GetCurrentBranch(repoPath string) (string, error) {
 
 // SafePull performs a pull and checks for conflicts
 func SafePull(repoPath string) error {
+	if !pullEnabled {
+		return nil
+	}
+
 	// Check if there are remote branches (skip pull for empty repos)
 	hasRemote, err := HasRemoteBranches(repoPath)
 	if err != nil {
@@ -229,6 +260,11 @@ func CommitAndPush(repoPath, message string, paths ...string) error {
 		return fmt.Errorf("failed to commit: %w", err)
 	}
 
+	// Leave pushing to the user when disabled
+	if !pushEnabled {
+		return nil
+	}
+
 	// Check if there are remote branches (skip pull for empty repos)
 	hasRemote, err := HasRemoteBranches(repoPath)
 	if err != nil {
@@ -0,0 +1,133 @@
+// Package process runs child processes the way a supervisor or init does:
+// signals are forwarded to the child, its exit status is propagated and, as
+// PID 1, orphaned processes are reaped.
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ExitError reports that a child process did not exit successfully
+type ExitError struct {
+	// Code is the exit code, or 128 + the signal number for signal deaths
+	Code int
+	// Signal is the signal that killed the child, or 0
+	Signal syscall.Signal
+}
+
+func (e *ExitError) Error() string {
+	if e.Signal != 0 {
+		return fmt.Sprintf("command killed by signal %v", e.Signal)
+	}
+	return fmt.Sprintf("command exited with status %d", e.Code)
+}
+
+// Exit terminates the current process the way the child terminated: by the
+// same signal, or with the same exit code
+func (e *ExitError) Exit() {
+	if e.Signal != 0 {
+		raise(e.Signal)
+	}
+	os.Exit(e.Code)
+}
+
+// reapMu serializes reaping with HoldReaping. While holds > 0, orphans are
+// not reaped.
+var (
+	reapMu sync.Mutex
+	holds  int
+)
+
+// HoldReaping pauses the reaping of orphaned processes until release is
+// called. nvolt must hold it while it runs and waits for subprocesses of its
+// own (such as git) during supervision; reaping would otherwise collect them
+// before their exec.Cmd.Wait does.
+func HoldReaping() (release func()) {
+	reapMu.Lock()
+	holds++
+	reapMu.Unlock()
+
+	return sync.OnceFunc(func() {
+		reapMu.Lock()
+		holds--
+		reapMu.Unlock()
+		// Collect whatever exited in the meantime
+		resumeReaping()
+	})
+}
+
+// Child is a started child process
+type Child struct {
+	cmd  *exec.Cmd
+	done chan struct{}
+	err  error
+
+	platformState
+}
+
+// Run starts cmd, forwards signals to it and waits for it to exit. It
+// returns an *ExitError if the child did not exit with status 0.
+func Run(cmd *exec.Cmd) error {
+	child, err := Start(cmd)
+	if err != nil {
+		return err
+	}
+	return child.Wait()
+}
+
+// Wait waits for the child to exit and returns nil or an *ExitError
+func (c *Child) Wait() error {
+	<-c.done
+	return c.err
+}
+
+// Done is closed when the child has exited
+func (c *Child) Done() <-chan struct{} {
+	return c.done
+}
+
+// Pid returns the process ID of the child
+func (c *Child) Pid() int {
+	return c.cmd.Process.Pid
+}
+
+// Signal sends a signal to the child
+func (c *Child) Signal(sig os.Signal) error {
+	select {
+	case <-c.done:
+		return nil
+	default:
+	}
+	return c.cmd.Process.Signal(sig)
+}
+
+// Stop sends sig to the child and kills it if it has not exited after
+// grace. It returns once the child has exited.
+func (c *Child) Stop(sig os.Signal, grace time.Duration) error {
+	if err := c.Signal(sig); err != nil {
+		_ = c.cmd.Process.Kill()
+	}
+
+	select {
+	case <-c.done:
+	case <-time.After(grace):
+		_ = c.cmd.Process.Kill()
+		<-c.done
+	}
+	return c.err
+}
+
+// ParseSignal parses a signal name such as TERM, SIGTERM or sigterm
+func ParseSignal(name string) (os.Signal, error) {
+	sig, ok := signalNames[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+	if !ok {
+		return nil, fmt.Errorf("unknown signal '%s'", name)
+	}
+	return sig, nil
+}
@@ -0,0 +1,187 @@
+//go:build !windows
+
+package process
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// terminalSignals are sent by the terminal to its whole foreground process
+// group, so a child sharing our group already receives them
+var terminalSignals = map[syscall.Signal]bool{
+	syscall.SIGINT:   true,
+	syscall.SIGQUIT:  true,
+	syscall.SIGWINCH: true,
+}
+
+// jobControlSignals keep their default action on a terminal so that ^Z
+// stops nvolt together with the child and returns control to the shell
+var jobControlSignals = []os.Signal{syscall.SIGTSTP, syscall.SIGTTIN, syscall.SIGTTOU}
+
+// signalNames are the signals accepted by ParseSignal
+var signalNames = map[string]os.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"TERM": syscall.SIGTERM,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
+
+// reapOrphans is set when nvolt runs as PID 1, where orphaned processes are
+// re-parented to it
+var reapOrphans = os.Getpid() == 1
+
+type platformState struct {
+	signals     chan os.Signal
+	interactive bool
+	reaper      bool
+	reaped      chan syscall.WaitStatus
+}
+
+// Start starts cmd and forwards every signal nvolt receives to it, except
+// signals the terminal already delivered to the child. As PID 1 it also
+// reaps orphaned processes that are re-parented to it.
+func Start(cmd *exec.Cmd) (*Child, error) {
+	c := &Child{cmd: cmd, done: make(chan struct{})}
+	c.interactive = isTerminal(os.Stdin) && (cmd.SysProcAttr == nil || !cmd.SysProcAttr.Setpgid)
+	c.reaper = reapOrphans
+	c.reaped = make(chan syscall.WaitStatus, 1)
+
+	// Subscribe before starting so that no signal is lost
+	c.signals = make(chan os.Signal, 32)
+	signal.Notify(c.signals)
+	if c.interactive {
+		signal.Reset(jobControlSignals...)
+	}
+
+	if err := cmd.Start(); err != nil {
+		signal.Stop(c.signals)
+		return nil, err
+	}
+
+	go c.forward()
+	go c.wait()
+	return c, nil
+}
+
+// forward relays signals to the child until it exits
+func (c *Child) forward() {
+	defer signal.Stop(c.signals)
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case sig := <-c.signals:
+			s, ok := sig.(syscall.Signal)
+			if !ok {
+				continue
+			}
+			switch {
+			case s == syscall.SIGCHLD:
+				if c.reaper {
+					c.reap()
+				}
+				continue
+			case s == syscall.SIGURG || s == syscall.SIGPIPE:
+				// SIGURG is used internally by the Go runtime
+				continue
+			case c.interactive && terminalSignals[s]:
+				continue
+			}
+			_ = c.cmd.Process.Signal(s)
+		}
+	}
+}
+
+// reap collects every exited child, remembering the status of ours. It
+// does nothing while HoldReaping is in effect.
+func (c *Child) reap() {
+	reapMu.Lock()
+	defer reapMu.Unlock()
+	if holds > 0 {
+		return
+	}
+
+	for {
+		var status syscall.WaitStatus
+		pid, err := syscall.Wait4(-1, &status, syscall.WNOHANG, nil)
+		if pid <= 0 || err != nil {
+			return
+		}
+		if pid == c.cmd.Process.Pid {
+			c.reaped <- status
+		}
+	}
+}
+
+func (c *Child) wait() {
+	defer close(c.done)
+
+	if c.reaper {
+		// The child is reaped by reap; Wait only releases its resources
+		status := <-c.reaped
+		_ = c.cmd.Wait()
+		c.err = statusError(status)
+		return
+	}
+
+	err := c.cmd.Wait()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		c.err = err
+		return
+	}
+	if status, ok := c.cmd.ProcessState.Sys().(syscall.WaitStatus); ok {
+		c.err = statusError(status)
+	} else if code := c.cmd.ProcessState.ExitCode(); code != 0 {
+		c.err = &ExitError{Code: code}
+	}
+}
+
+func statusError(status syscall.WaitStatus) error {
+	switch {
+	case status.Signaled():
+		return &ExitError{Code: 128 + int(status.Signal()), Signal: status.Signal()}
+	case status.ExitStatus() != 0:
+		return &ExitError{Code: status.ExitStatus()}
+	}
+	return nil
+}
+
+// resumeReaping signals SIGCHLD to nvolt so that a supervising Child reaps
+// the processes that exited while reaping was held
+func resumeReaping() {
+	if reapOrphans {
+		_ = syscall.Kill(os.Getpid(), syscall.SIGCHLD)
+	}
+}
+
+// raise kills the current process with sig using its default action
+func raise(sig syscall.Signal) {
+	signal.Reset(sig)
+	_ = syscall.Kill(os.Getpid(), sig)
+	// Signals are delivered asynchronously; PID 1 ignores them entirely
+	time.Sleep(100 * time.Millisecond)
+}
+
+// Exec replaces the current process with argv, keeping its PID
+func Exec(argv, env []string) error {
+	path, err := exec.LookPath(argv[0])
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(path, argv, env)
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,148 @@
+//go:build !windows
+
+package process
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunExitStatus(t *testing.T) {
+	if err := Run(exec.Command("sh", "-c", "exit 0")); err != nil {
+		t.Errorf("Run(exit 0) = %v, want nil", err)
+	}
+
+	err := Run(exec.Command("sh", "-c", "exit 3"))
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 3 || exitErr.Signal != 0 {
+		t.Errorf("Run(exit 3) = %#v, want exit code 3", err)
+	}
+}
+
+func TestRunSignalDeath(t *testing.T) {
+	err := Run(exec.Command("sh", "-c", "kill -TERM $$"))
+	var exitErr *ExitError
+	if !errors.As(err, &exitErr) || exitErr.Signal != syscall.SIGTERM || exitErr.Code != 128+int(syscall.SIGTERM) {
+		t.Errorf("Run(kill -TERM $$) = %#v, want SIGTERM death", err)
+	}
+}
+
+func TestRunStartFailure(t *testing.T) {
+	err := Run(exec.Command("/nonexistent/command"))
+	var exitErr *ExitError
+	if err == nil || errors.As(err, &exitErr) {
+		t.Errorf("Run(missing command) = %v, want a start error", err)
+	}
+}
+
+func TestStartForwardsSignals(t *testing.T) {
+	cmd := exec.Command("sh", "-c", `trap "exit 7" USR1; echo ready; while :; do sleep 0.05; done`)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	child, err := Start(cmd)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if _, err := bufio.NewReader(stdout).ReadString('\n'); err != nil {
+		t.Fatalf("child did not start: %v", err)
+	}
+
+	// A signal sent to nvolt reaches the child
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-child.Done():
+	case <-time.After(5 * time.Second):
+		_ = child.Signal(syscall.SIGKILL)
+		t.Fatal("child did not exit after SIGUSR1")
+	}
+
+	var exitErr *ExitError
+	if err := child.Wait(); !errors.As(err, &exitErr) || exitErr.Code != 7 {
+		t.Errorf("Wait() = %v, want exit code 7 from the trap", err)
+	}
+}
+
+func TestStopKillsAfterGrace(t *testing.T) {
+	cmd := exec.Command("sh", "-c", `trap "" TERM; echo ready; while :; do sleep 0.05; done`)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	child, err := Start(cmd)
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if _, err := bufio.NewReader(stdout).ReadString('\n'); err != nil {
+		t.Fatalf("child did not start: %v", err)
+	}
+
+	// The child ignores SIGTERM, so Stop has to kill it
+	var exitErr *ExitError
+	if err := child.Stop(syscall.SIGTERM, 100*time.Millisecond); !errors.As(err, &exitErr) || exitErr.Signal != syscall.SIGKILL {
+		t.Errorf("Stop() = %v, want SIGKILL death", err)
+	}
+}
+
+func TestHoldReaping(t *testing.T) {
+	saved := reapOrphans
+	t.Cleanup(func() { reapOrphans = saved })
+	reapOrphans = true
+
+	child, err := Start(exec.Command("sh", "-c", "sleep 0.1"))
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	// While reaping is held, the reaper leaves other subprocesses to their
+	// own Wait
+	release := HoldReaping()
+	for i := 0; i < 3; i++ {
+		cmd := exec.Command("sh", "-c", "exit 3")
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		// Give the SIGCHLD of the exit time to reach the reaper first
+		time.Sleep(50 * time.Millisecond)
+		err := cmd.Wait()
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+			t.Errorf("Wait(exit 3) while holding = %v, want exit code 3", err)
+		}
+	}
+	release()
+
+	// The child is reaped once the hold is released, even if it exited
+	// while held
+	select {
+	case <-child.Done():
+	case <-time.After(5 * time.Second):
+		_ = child.Signal(syscall.SIGKILL)
+		t.Fatal("child was not reaped after release")
+	}
+	if err := child.Wait(); err != nil {
+		t.Errorf("Wait() = %v, want nil", err)
+	}
+}
+
+func TestParseSignal(t *testing.T) {
+	for _, name := range []string{"TERM", "SIGTERM", "sigterm"} {
+		if sig, err := ParseSignal(name); err != nil || sig != syscall.SIGTERM {
+			t.Errorf("ParseSignal(%q) = %v, %v, want SIGTERM", name, sig, err)
+		}
+	}
+	if _, err := ParseSignal("NOPE"); err == nil {
+		t.Error("ParseSignal(NOPE) succeeded, want an error")
+	}
+}
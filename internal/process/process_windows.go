@@ -0,0 +1,67 @@
+//go:build windows
+
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+)
+
+// signalNames are the signals accepted by ParseSignal; Windows can only
+// interrupt or kill a process
+var signalNames = map[string]os.Signal{
+	"INT":  os.Interrupt,
+	"KILL": os.Kill,
+	"TERM": os.Kill,
+}
+
+type platformState struct {
+	signals chan os.Signal
+}
+
+// Start starts cmd. Windows delivers console Ctrl+C to every process of the
+// console, so nvolt only ignores it and lets the child decide.
+func Start(cmd *exec.Cmd) (*Child, error) {
+	c := &Child{cmd: cmd, done: make(chan struct{})}
+
+	c.signals = make(chan os.Signal, 1)
+	signal.Notify(c.signals, os.Interrupt)
+
+	if err := cmd.Start(); err != nil {
+		signal.Stop(c.signals)
+		return nil, err
+	}
+
+	go c.wait()
+	return c, nil
+}
+
+func (c *Child) wait() {
+	defer close(c.done)
+	defer signal.Stop(c.signals)
+
+	err := c.cmd.Wait()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		c.err = err
+		return
+	}
+	if code := c.cmd.ProcessState.ExitCode(); code != 0 {
+		c.err = &ExitError{Code: code}
+	}
+}
+
+// resumeReaping is a no-op: Windows has no orphans to reap
+func resumeReaping() {}
+
+// raise is a no-op: Windows has no signal deaths to reproduce
+func raise(sig syscall.Signal) {}
+
+// Exec is not available on Windows
+func Exec(argv, env []string) error {
+	return fmt.Errorf("replacing the nvolt process is not supported on Windows")
+}
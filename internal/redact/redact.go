@@ -0,0 +1,160 @@
+// Package redact masks secret values in streamed output, including their
+// common encodings, without delaying output that cannot contain a secret.
+package redact
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/url"
+	"slices"
+	"sync"
+)
+
+// MinLength is the length below which values are not redacted: masking
+// every "1" or "on" would make the output unreadable
+const MinLength = 4
+
+// pattern is one form of a secret value and its replacement
+type pattern struct {
+	text []byte
+	mask []byte
+}
+
+// Redactor finds secret values in text
+type Redactor struct {
+	// byFirst indexes patterns by their first byte, longest first
+	byFirst map[byte][]pattern
+}
+
+// New returns a Redactor masking each value of secrets as ***KEY***. Besides
+// the value itself, its base64, URL-encoded and JSON-escaped forms are masked.
+func New(secrets map[string]string) *Redactor {
+	keys := make([]string, 0, len(secrets))
+	for key := range secrets {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	// The first key wins when several secrets share a value
+	seen := make(map[string]bool)
+	r := &Redactor{byFirst: make(map[byte][]pattern)}
+	for _, key := range keys {
+		value := secrets[key]
+		if len(value) < MinLength {
+			continue
+		}
+		mask := []byte("***" + key + "***")
+		for _, form := range encodings(value) {
+			if seen[form] {
+				continue
+			}
+			seen[form] = true
+			r.byFirst[form[0]] = append(r.byFirst[form[0]], pattern{text: []byte(form), mask: mask})
+		}
+	}
+
+	for _, patterns := range r.byFirst {
+		slices.SortStableFunc(patterns, func(a, b pattern) int {
+			return len(b.text) - len(a.text)
+		})
+	}
+	return r
+}
+
+// encodings returns the forms of value that are masked
+func encodings(value string) []string {
+	forms := []string{
+		value,
+		// Unpadded, so that the value is also found inside longer payloads
+		base64.RawStdEncoding.EncodeToString([]byte(value)),
+		base64.RawURLEncoding.EncodeToString([]byte(value)),
+		url.QueryEscape(value),
+		url.PathEscape(value),
+	}
+	if quoted, err := json.Marshal(value); err == nil {
+		forms = append(forms, string(quoted[1:len(quoted)-1]))
+	}
+	return forms
+}
+
+// Redact returns text with every secret masked
+func (r *Redactor) Redact(text string) string {
+	out, _ := r.redact(nil, []byte(text), true)
+	return string(out)
+}
+
+// redact appends data with secrets masked to out. Unless final, it stops at
+// a trailing part of data that may be the start of a secret and returns it.
+func (r *Redactor) redact(out, data []byte, final bool) ([]byte, []byte) {
+	start := 0
+	for i := 0; i < len(data); {
+		matched := false
+		for _, p := range r.byFirst[data[i]] {
+			rest := data[i:]
+			if bytes.HasPrefix(rest, p.text) {
+				out = append(out, data[start:i]...)
+				out = append(out, p.mask...)
+				i += len(p.text)
+				start = i
+				matched = true
+				break
+			}
+			// Wait for more data before deciding
+			if !final && len(rest) < len(p.text) && bytes.HasPrefix(p.text, rest) {
+				out = append(out, data[start:i]...)
+				return out, data[i:]
+			}
+		}
+		if !matched {
+			i++
+		}
+	}
+	return append(out, data[start:]...), nil
+}
+
+// Writer redacts everything written to it before passing it on. Output is
+// passed on immediately, except a trailing part that may start a secret.
+type Writer struct {
+	mu      sync.Mutex
+	w       io.Writer
+	r       *Redactor
+	pending []byte
+}
+
+// NewWriter returns a Writer that writes to w
+func NewWriter(w io.Writer, r *Redactor) *Writer {
+	return &Writer{w: w, r: r}
+}
+
+// Write redacts p and writes it to the underlying writer
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data := append(w.pending, p...)
+	out, pending := w.r.redact(nil, data, false)
+	w.pending = slices.Clone(pending)
+
+	if len(out) > 0 {
+		if _, err := w.w.Write(out); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush writes output held back because it may have started a secret
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) == 0 {
+		return nil
+	}
+	out, _ := w.r.redact(nil, w.pending, true)
+	w.pending = nil
+	_, err := w.w.Write(out)
+	return err
+}
@@ -0,0 +1,74 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/url"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	r := New(map[string]string{
+		"API_KEY":  "s3cr3t-value",
+		"PASSWORD": "p@ss word/1",
+		"PORT":     "443",
+		"ALIAS":    "s3cr3t-value",
+	})
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"key=s3cr3t-value!", "key=***ALIAS***!"},
+		{"pw: p@ss word/1", "pw: ***PASSWORD***"},
+		{"port 443", "port 443"},
+		{"b64 " + base64.StdEncoding.EncodeToString([]byte("s3cr3t-value")), "b64 ***ALIAS***"},
+		{"url ?p=" + url.QueryEscape("p@ss word/1"), "url ?p=***PASSWORD***"},
+		{"path /" + url.PathEscape("p@ss word/1"), "path /***PASSWORD***"},
+		{"s3cr3t-valu", "s3cr3t-valu"},
+	}
+
+	for _, tt := range tests {
+		if got := r.Redact(tt.in); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRedactJSONEscaped(t *testing.T) {
+	r := New(map[string]string{"PEM": "line1\nline2"})
+	if got, want := r.Redact(`{"pem":"line1\nline2"}`), `{"pem":"***PEM***"}`; got != want {
+		t.Errorf("Redact = %q, want %q", got, want)
+	}
+}
+
+func TestRedactPrefersLongestMatch(t *testing.T) {
+	r := New(map[string]string{"SHORT": "abcd", "LONG": "abcdefgh"})
+	if got, want := r.Redact("abcdefgh abcdxx"), "***LONG*** ***SHORT***xx"; got != want {
+		t.Errorf("Redact = %q, want %q", got, want)
+	}
+}
+
+func TestWriterSplitWrites(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, New(map[string]string{"TOKEN": "tok-12345"}))
+
+	// A secret split across writes is still masked
+	for _, chunk := range []string{"a tok-1", "23", "45 b\n", "partial to"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only output that may start a secret is held back
+	if got, want := out.String(), "a ***TOKEN*** b\npartial "; got != want {
+		t.Errorf("before Flush: %q, want %q", got, want)
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.String(), "a ***TOKEN*** b\npartial to"; got != want {
+		t.Errorf("after Flush: %q, want %q", got, want)
+	}
+}
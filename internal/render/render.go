@@ -0,0 +1,107 @@
+// Package render fills text/template templates with decrypted secrets.
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Data is the value templates are executed with
+type Data struct {
+	// Environment is the name of the environment the secrets come from
+	Environment string
+	// Secrets maps secret keys to values; {{ .Secrets.KEY }} fails for
+	// missing keys while {{ index .Secrets "KEY" }} yields ""
+	Secrets map[string]string
+}
+
+// Funcs returns the helper functions available to templates
+func Funcs(secrets map[string]string) template.FuncMap {
+	return template.FuncMap{
+		// secret "KEY" returns a secret and fails if it does not exist
+		"secret": func(key string) (string, error) {
+			value, ok := secrets[key]
+			if !ok {
+				return "", fmt.Errorf("secret %s not found", key)
+			}
+			return value, nil
+		},
+		// hasSecret "KEY" reports whether a secret exists
+		"hasSecret": func(key string) bool {
+			_, ok := secrets[key]
+			return ok
+		},
+		"base64": func(value string) string {
+			return base64.StdEncoding.EncodeToString([]byte(value))
+		},
+		"base64decode": func(value string) (string, error) {
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return "", fmt.Errorf("base64decode: %w", err)
+			}
+			return string(decoded), nil
+		},
+		// json returns value as a quoted JSON string
+		"json": func(value string) (string, error) {
+			var buf bytes.Buffer
+			encoder := json.NewEncoder(&buf)
+			encoder.SetEscapeHTML(false)
+			if err := encoder.Encode(value); err != nil {
+				return "", err
+			}
+			return strings.TrimSuffix(buf.String(), "\n"), nil
+		},
+		// default "fallback" value returns fallback when value is empty
+		"default": func(fallback, value string) string {
+			if value == "" {
+				return fallback
+			}
+			return value
+		},
+		// required "message" value fails with message when value is empty
+		"required": func(message, value string) (string, error) {
+			if value == "" {
+				return "", errors.New(message)
+			}
+			return value, nil
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"trim":  strings.TrimSpace,
+		// indent n value indents every line of value by n spaces
+		"indent": func(n int, value string) string {
+			pad := strings.Repeat(" ", n)
+			return pad + strings.ReplaceAll(value, "\n", "\n"+pad)
+		},
+		// keys returns the sorted secret keys
+		"keys": func() []string {
+			keys := make([]string, 0, len(secrets))
+			for key := range secrets {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			return keys
+		},
+	}
+}
+
+// Render parses and executes a template; referencing a missing secret with
+// {{ .Secrets.KEY }} or {{ secret "KEY" }} is an error
+func Render(name, text string, data Data) (string, error) {
+	tmpl, err := template.New(name).Funcs(Funcs(data.Secrets)).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.String(), nil
+}
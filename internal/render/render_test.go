@@ -0,0 +1,63 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	data := Data{
+		Environment: "production",
+		Secrets: map[string]string{
+			"DB_USER":     "admin",
+			"DB_PASSWORD": `p"ss`,
+			"CERT":        "line1\nline2",
+		},
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{`{{ .Environment }}: {{ .Secrets.DB_USER }}`, "production: admin"},
+		{`{{ secret "DB_USER" | upper }}`, "ADMIN"},
+		{`{{ .Secrets.DB_USER | base64 }}`, "YWRtaW4="},
+		{`{{ "YWRtaW4=" | base64decode }}`, "admin"},
+		{`password = {{ json .Secrets.DB_PASSWORD }}`, `password = "p\"ss"`},
+		{`{{ index .Secrets "MISSING" | default "5432" }}`, "5432"},
+		{`{{ .Secrets.DB_USER | default "root" }}`, "admin"},
+		{`{{ if hasSecret "MISSING" }}yes{{ else }}no{{ end }}`, "no"},
+		{"cert: |\n{{ indent 2 .Secrets.CERT }}", "cert: |\n  line1\n  line2"},
+		{`{{ range keys }}{{ . }} {{ end }}`, "CERT DB_PASSWORD DB_USER "},
+	}
+
+	for _, tt := range tests {
+		got, err := Render("test", tt.template, data)
+		if err != nil {
+			t.Errorf("Render(%q) failed: %v", tt.template, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Render(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	data := Data{Environment: "dev", Secrets: map[string]string{"EMPTY": ""}}
+
+	tests := map[string]string{
+		`{{ .Secrets.MISSING }}`:                            "MISSING",
+		`{{ secret "MISSING" }}`:                            "secret MISSING not found",
+		`{{ required "EMPTY must be set" .Secrets.EMPTY }}`: "EMPTY must be set",
+		`{{ "%%%" | base64decode }}`:                        "base64decode",
+		`{{ .Secrets.EMPTY `:                                "failed to parse template",
+	}
+
+	for template, want := range tests {
+		_, err := Render("test", template, data)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Render(%q) error = %v, want %q", template, err, want)
+		}
+	}
+}
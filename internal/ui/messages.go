@@ -22,7 +22,7 @@ func PrintGlobalUsage() {
 
 // Section prints a section header
 func Section(message string) {
-	fmt.Printf("\n%s\n", Cyan(message))
+	fmt.Fprintf(defaultLogger.output, "\n%s\n", Cyan(message))
 }
 
 // Substep prints a substep with indentation
@@ -17,6 +17,32 @@ func ValidateNotEmpty(field, value string) error {
 	return nil
 }
 
+// MaxNameLength is the longest secret key, environment, project or machine ID.
+// Names become file names in the vault.
+const MaxNameLength = 128
+
+// Name grammars. None admits a path separator or a leading ".", so a valid
+// name can never escape its directory in the vault.
+var (
+	secretKeyPattern   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	environmentPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	projectPattern     = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+	machineIDPattern   = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.+-]*$`)
+)
+
+// ValidateSecretKey validates a secret key (an environment variable name)
+func ValidateSecretKey(key string) error {
+	if key == "" {
+		return errors.NewInvalidInput("secret key", key, "secret key cannot be empty")
+	}
+
+	if len(key) > MaxNameLength || !secretKeyPattern.MatchString(key) {
+		return errors.NewInvalidInput("secret key", key, "must start with a letter or underscore and contain only letters, numbers, and underscores")
+	}
+
+	return nil
+}
+
 // ValidateEnvironmentName validates an environment name
 func ValidateEnvironmentName(env string) error {
 	if env == "" {
@@ -24,24 +50,32 @@ func ValidateEnvironmentName(env string) error {
 	}
 
 	// Environment names should be alphanumeric with dashes/underscores
-	match := regexp.MustCompile(`^[a-zA-Z0-9_-]+$`).MatchString(env)
-	if !match {
+	if len(env) > MaxNameLength || !environmentPattern.MatchString(env) {
 		return errors.NewInvalidInput("environment", env, "must contain only letters, numbers, dashes, and underscores")
 	}
 
 	return nil
 }
 
+// ValidateEnvironmentNames validates several environment names
+func ValidateEnvironmentNames(environments ...string) error {
+	for _, env := range environments {
+		if err := ValidateEnvironmentName(env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ValidateProjectName validates a project name
 func ValidateProjectName(project string) error {
 	if project == "" {
 		return errors.NewInvalidInput("project", project, "project name cannot be empty")
 	}
 
-	// Project names can contain alphanumeric, dashes, underscores, slashes, dots
-	match := regexp.MustCompile(`^[a-zA-Z0-9_.\-/]+$`).MatchString(project)
-	if !match {
-		return errors.NewInvalidInput("project", project, "must contain only letters, numbers, dashes, underscores, slashes, and dots")
+	// Project names are a single directory of a global vault
+	if len(project) > MaxNameLength || !projectPattern.MatchString(project) {
+		return errors.NewInvalidInput("project", project, "must start with a letter or number and contain only letters, numbers, dashes, underscores, and dots")
 	}
 
 	return nil
@@ -53,10 +87,9 @@ func ValidateMachineID(machineID string) error {
 		return errors.NewInvalidInput("machine ID", machineID, "machine ID cannot be empty")
 	}
 
-	// Machine IDs should start with 'm-' and contain alphanumeric/dashes/underscores
-	match := regexp.MustCompile(`^m-[a-zA-Z0-9_-]+$`).MatchString(machineID)
-	if !match {
-		return errors.NewInvalidInput("machine ID", machineID, "must start with 'm-' and contain only letters, numbers, dashes, and underscores")
+	// Machine IDs are derived from names and key fingerprints, e.g. m-laptop-Ab3+x9Q
+	if len(machineID) > MaxNameLength || !machineIDPattern.MatchString(machineID) {
+		return errors.NewInvalidInput("machine ID", machineID, "must start with a letter or number and contain only letters, numbers, dashes, underscores, dots, and plus signs")
 	}
 
 	return nil
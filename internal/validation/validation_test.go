@@ -0,0 +1,58 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNameValidators(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(string) error
+		valid    []string
+		invalid  []string
+	}{
+		{
+			"secret key", ValidateSecretKey,
+			[]string{"API_KEY", "_private", "db_url2"},
+			[]string{"", "../../machines/evil", "2FA", "MY-KEY", "A B", "a.b", strings.Repeat("A", MaxNameLength+1)},
+		},
+		{
+			"environment", ValidateEnvironmentName,
+			[]string{"default", "prod-eu", "staging_2"},
+			[]string{"", "..", "../x", "a/b", "prod.eu"},
+		},
+		{
+			"project", ValidateProjectName,
+			[]string{"api", "my.service", "web_2"},
+			[]string{"", ".", "..", ".hidden", "a/b", `a\b`},
+		},
+		{
+			"machine ID", ValidateMachineID,
+			[]string{"m-laptop-Ab3+x9Q", "ci-server-1a2b3c4", "m-host.local-abc"},
+			[]string{"", "..", "../m-test", "m/x", ".m"},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, value := range tt.valid {
+			if err := tt.validate(value); err != nil {
+				t.Errorf("%s %q should be valid: %v", tt.name, value, err)
+			}
+		}
+		for _, value := range tt.invalid {
+			if err := tt.validate(value); err == nil {
+				t.Errorf("%s %q should be invalid", tt.name, value)
+			}
+		}
+	}
+}
+
+func TestValidateEnvironmentNames(t *testing.T) {
+	if err := ValidateEnvironmentNames("staging", "production"); err != nil {
+		t.Errorf("ValidateEnvironmentNames failed: %v", err)
+	}
+	if err := ValidateEnvironmentNames("staging", "../production"); err == nil {
+		t.Error("ValidateEnvironmentNames should reject any invalid name")
+	}
+}
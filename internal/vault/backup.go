@@ -0,0 +1,621 @@
+package vault
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iluxav/nvolt/internal/crypto"
+	"github.com/iluxav/nvolt/internal/validation"
+	"github.com/iluxav/nvolt/pkg/types"
+)
+
+const (
+	// BackupFormatVersion is the archive format written by this version of nvolt
+	BackupFormatVersion = 1
+
+	// BackupKeyID is the wrapped key owner used for the backup public key
+	BackupKeyID = "backup"
+
+	backupManifestFile = "manifest.json"
+	backupKeysDir      = "backup_keys"
+)
+
+// BackupManifest describes the contents of a backup archive
+type BackupManifest struct {
+	Version              int               `json:"version"`
+	CreatedAt            time.Time         `json:"created_at"`
+	CreatedBy            string            `json:"created_by"`
+	SchemaVersion        int               `json:"schema_version"`
+	Mode                 string            `json:"mode"`
+	Repository           string            `json:"repository,omitempty"`
+	Projects             []BackupProject   `json:"projects"`
+	BackupKeyFingerprint string            `json:"backup_key_fingerprint,omitempty"`
+	Files                map[string]string `json:"files"` // Archive path -> SHA-256
+}
+
+// BackupProject lists the environments of a project in a backup.
+// Local vaults are stored as a single project with an empty name.
+type BackupProject struct {
+	Name         string   `json:"name"`
+	Environments []string `json:"environments"`
+}
+
+// BackupOptions selects what goes into a backup
+type BackupOptions struct {
+	Projects     []string       // Empty means all projects
+	Environments []string       // Empty means all environments
+	BackupKey    *rsa.PublicKey // If set, every master key is also wrapped for this key
+	CreatedBy    string         // Machine ID of the creator
+}
+
+// Backup is a backup archive whose integrity has been verified
+type Backup struct {
+	Manifest *BackupManifest
+	files    map[string][]byte
+}
+
+// RestoreOptions controls how a backup is restored
+type RestoreOptions struct {
+	Project   string             // Target project when restoring a single project into a global vault
+	BackupKey *rsa.PrivateKey    // If set, Machine is granted access through the backup key
+	Machine   *types.MachineInfo // Machine to grant access with the backup key
+}
+
+// RestoreResult describes what was restored
+type RestoreResult struct {
+	MachinesAdded   []string
+	MachinesSkipped []string // Already registered in the target vault
+	Projects        []string // Target project names
+	Environments    int
+	Secrets         int
+	Granted         []string // "project/environment" granted through the backup key
+}
+
+// backupProjectDir returns the archive directory of a project
+func backupProjectDir(project string) string {
+	if project == "" {
+		return "projects/_local"
+	}
+	return "projects/" + project
+}
+
+// CreateBackup writes a gzipped tar archive of the machines, secrets and
+// wrapped keys of the selected projects and environments, with a manifest
+// holding a SHA-256 checksum of every file.
+func CreateBackup(w io.Writer, vaultPath string, opts BackupOptions) (*BackupManifest, error) {
+	projects := opts.Projects
+	if len(projects) == 0 {
+		var err error
+		if projects, err = ListProjects(vaultPath); err != nil {
+			return nil, fmt.Errorf("failed to list projects: %w", err)
+		}
+	}
+
+	cfg := NewVaultConfig(vaultPath)
+	manifest := &BackupManifest{
+		Version:    BackupFormatVersion,
+		CreatedAt:  time.Now().UTC(),
+		CreatedBy:  opts.CreatedBy,
+		Mode:       cfg.Mode,
+		Repository: cfg.Repository,
+		Files:      make(map[string]string),
+	}
+	if version, err := GetSchemaVersion(vaultPath); err == nil {
+		manifest.SchemaVersion = version
+	}
+
+	var fingerprint string
+	if opts.BackupKey != nil {
+		var err error
+		if fingerprint, err = crypto.GenerateFingerprint(opts.BackupKey); err != nil {
+			return nil, err
+		}
+		manifest.BackupKeyFingerprint = fingerprint
+	}
+
+	storage, err := StorageFor(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+
+	if err := addBackupDir(storage, files, GetVaultPaths(vaultPath, "").Machines, MachinesDir); err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool)
+	for _, env := range opts.Environments {
+		wanted[env] = false
+	}
+
+	for _, project := range projects {
+		paths := GetVaultPaths(vaultPath, project)
+		dir := backupProjectDir(project)
+
+		environments, err := ListEnvironments(paths)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list environments: %w", err)
+		}
+
+		entry := BackupProject{Name: project}
+		for _, env := range environments {
+			if len(opts.Environments) > 0 {
+				if _, ok := wanted[env]; !ok {
+					continue
+				}
+				wanted[env] = true
+			}
+
+			if err := addBackupDir(storage, files, paths.GetSecretsPath(env), path.Join(dir, SecretsDir, env)); err != nil {
+				return nil, err
+			}
+			if err := addBackupDir(storage, files, paths.GetWrappedKeysEnvPath(env), path.Join(dir, WrappedKeysDir, env)); err != nil {
+				return nil, err
+			}
+
+			if opts.BackupKey != nil {
+				data, err := wrapForBackupKey(paths, env, opts.BackupKey, fingerprint, opts.CreatedBy)
+				if err != nil {
+					return nil, fmt.Errorf("failed to wrap master key of '%s' for the backup key: %w", path.Join(project, env), err)
+				}
+				files[path.Join(dir, backupKeysDir, env+".json")] = data
+			}
+
+			entry.Environments = append(entry.Environments, env)
+		}
+
+		if len(entry.Environments) > 0 {
+			manifest.Projects = append(manifest.Projects, entry)
+		}
+	}
+
+	for env, found := range wanted {
+		if !found {
+			return nil, fmt.Errorf("environment '%s' not found", env)
+		}
+	}
+	if len(manifest.Projects) == 0 {
+		return nil, fmt.Errorf("nothing to back up")
+	}
+
+	for name, data := range files {
+		manifest.Files[name] = checksum(data)
+	}
+
+	if err := writeBackupArchive(w, manifest, files); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// ReadBackup reads a backup archive and verifies the checksum of every file
+func ReadBackup(r io.Reader) (*Backup, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup archive: %w", err)
+		}
+
+		name := header.Name
+		if header.Typeflag != tar.TypeReg || path.IsAbs(name) || path.Clean(name) != name || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("backup archive contains an invalid entry: %s", name)
+		}
+		if _, ok := files[name]; ok {
+			return nil, fmt.Errorf("backup archive contains a duplicate entry: %s", name)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from backup archive: %w", name, err)
+		}
+		files[name] = data
+	}
+
+	data, ok := files[backupManifestFile]
+	if !ok {
+		return nil, fmt.Errorf("backup archive has no manifest")
+	}
+	delete(files, backupManifestFile)
+
+	var manifest BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse backup manifest: %w", err)
+	}
+	if manifest.Version > BackupFormatVersion {
+		return nil, fmt.Errorf("backup format version %d is newer than the supported version %d: upgrade nvolt", manifest.Version, BackupFormatVersion)
+	}
+
+	for _, project := range manifest.Projects {
+		if err := validateBackupProject(project); err != nil {
+			return nil, fmt.Errorf("backup manifest is invalid: %w", err)
+		}
+	}
+
+	for name, sum := range manifest.Files {
+		data, ok := files[name]
+		if !ok {
+			return nil, fmt.Errorf("backup is corrupt: %s is missing", name)
+		}
+		if checksum(data) != sum {
+			return nil, fmt.Errorf("backup is corrupt: checksum mismatch for %s", name)
+		}
+	}
+	for name := range files {
+		if _, ok := manifest.Files[name]; !ok {
+			return nil, fmt.Errorf("backup is corrupt: %s is not listed in the manifest", name)
+		}
+	}
+
+	return &Backup{Manifest: &manifest, files: files}, nil
+}
+
+// RestoreBackup writes the contents of a backup into a vault in a single
+// transaction. Machine lists are merged; environments that already exist in
+// the target are rejected before anything is written. Callers must hold the
+// vault lock.
+func RestoreBackup(b *Backup, vaultPath string, opts RestoreOptions) (*RestoreResult, error) {
+	targets, err := restoreTargets(b.Manifest, vaultPath, opts.Project)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, project := range b.Manifest.Projects {
+		paths := GetVaultPaths(vaultPath, targets[i])
+		for _, env := range project.Environments {
+			if EnvironmentExists(paths, env) {
+				return nil, fmt.Errorf("environment '%s' already exists in target vault", path.Join(targets[i], env))
+			}
+		}
+	}
+
+	if opts.BackupKey != nil {
+		if b.Manifest.BackupKeyFingerprint == "" {
+			return nil, fmt.Errorf("backup was not created with a backup key")
+		}
+		fingerprint, err := crypto.GenerateFingerprint(&opts.BackupKey.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		if fingerprint != b.Manifest.BackupKeyFingerprint {
+			return nil, fmt.Errorf("backup key does not match the key the backup was created with (%s)", b.Manifest.BackupKeyFingerprint)
+		}
+		if opts.Machine == nil {
+			return nil, fmt.Errorf("a machine is required to restore with a backup key")
+		}
+	}
+
+	tx, err := BeginTransaction(vaultPath, "restore backup")
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result := &RestoreResult{Projects: targets}
+	machinePaths := GetVaultPaths(vaultPath, "")
+
+	// Merge machine lists
+	machines := make(map[string]*types.MachineInfo)
+	for _, name := range b.filesUnder(MachinesDir) {
+		var machine types.MachineInfo
+		if err := json.Unmarshal(b.files[name], &machine); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		if machine.ID == "" || machine.ID+".json" != path.Base(name) {
+			return nil, fmt.Errorf("machine ID in %s does not match its file name", name)
+		}
+		machines[machine.ID] = &machine
+	}
+	if opts.BackupKey != nil {
+		if _, ok := machines[opts.Machine.ID]; !ok {
+			machines[opts.Machine.ID] = opts.Machine
+		}
+	}
+
+	ids := make([]string, 0, len(machines))
+	for id := range machines {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		machine := machines[id]
+		targetPath := machinePaths.GetMachineInfoPath(id)
+		if tx.Exists(targetPath) {
+			existing, err := loadMachineInfo(tx, targetPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load machine %s from target vault: %w", id, err)
+			}
+			if existing.PublicKey != machine.PublicKey {
+				return nil, fmt.Errorf("machine %s exists in the backup and the vault with different keys", id)
+			}
+			result.MachinesSkipped = append(result.MachinesSkipped, id)
+			continue
+		}
+
+		data, err := json.MarshalIndent(machine, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal machine info: %w", err)
+		}
+		if err := tx.WriteFile(targetPath, data, FilePerm); err != nil {
+			return nil, err
+		}
+		result.MachinesAdded = append(result.MachinesAdded, id)
+	}
+
+	// Secrets and wrapped keys
+	for i, project := range b.Manifest.Projects {
+		paths := GetVaultPaths(vaultPath, targets[i])
+		dir := backupProjectDir(project.Name)
+
+		for _, env := range project.Environments {
+			secrets := b.filesUnder(path.Join(dir, SecretsDir, env))
+			for _, name := range secrets {
+				if err := tx.WriteFile(filepath.Join(paths.GetSecretsPath(env), path.Base(name)), b.files[name], FilePerm); err != nil {
+					return nil, err
+				}
+			}
+			result.Secrets += len(secrets)
+
+			for _, name := range b.filesUnder(path.Join(dir, WrappedKeysDir, env)) {
+				if err := tx.WriteFile(filepath.Join(paths.GetWrappedKeysEnvPath(env), path.Base(name)), b.files[name], FilePerm); err != nil {
+					return nil, err
+				}
+			}
+
+			if opts.BackupKey != nil && !b.hasFile(path.Join(dir, WrappedKeysDir, env, opts.Machine.ID+".json")) {
+				data, err := b.grantFromBackupKey(path.Join(dir, backupKeysDir, env+".json"), opts)
+				if err != nil {
+					return nil, fmt.Errorf("failed to grant access to '%s': %w", path.Join(targets[i], env), err)
+				}
+				if err := tx.WriteFile(paths.GetWrappedKeyPath(env, opts.Machine.ID), data, FilePerm); err != nil {
+					return nil, err
+				}
+				result.Granted = append(result.Granted, path.Join(targets[i], env))
+			}
+
+			result.Environments++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// restoreTargets maps each project in the backup to a project in the target vault
+func restoreTargets(manifest *BackupManifest, vaultPath, project string) ([]string, error) {
+	if len(manifest.Projects) == 0 {
+		return nil, fmt.Errorf("backup contains no projects")
+	}
+
+	targets := make([]string, len(manifest.Projects))
+
+	if !IsGlobalMode(vaultPath) {
+		if len(manifest.Projects) > 1 {
+			return nil, fmt.Errorf("backup contains %d projects; a local vault holds only one", len(manifest.Projects))
+		}
+		return targets, nil
+	}
+
+	if project != "" {
+		if len(manifest.Projects) > 1 {
+			return nil, fmt.Errorf("backup contains %d projects; a target project can only be set for single-project backups", len(manifest.Projects))
+		}
+		targets[0] = project
+		return targets, nil
+	}
+
+	for i, p := range manifest.Projects {
+		if p.Name == "" {
+			return nil, fmt.Errorf("backup is from a local vault: specify the target project")
+		}
+		targets[i] = p.Name
+	}
+
+	return targets, nil
+}
+
+// validateBackupProject rejects project and environment names that could
+// escape their directory when restored
+func validateBackupProject(project BackupProject) error {
+	if project.Name != "" {
+		if err := validation.ValidateProjectName(project.Name); err != nil {
+			return err
+		}
+		for _, part := range strings.Split(project.Name, "/") {
+			if part == "" || part == "." || part == ".." {
+				return fmt.Errorf("invalid project name '%s'", project.Name)
+			}
+		}
+	}
+
+	for _, env := range project.Environments {
+		if err := validation.ValidateEnvironmentName(env); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// grantFromBackupKey unwraps a master key with the backup key and wraps it for the restoring machine
+func (b *Backup) grantFromBackupKey(name string, opts RestoreOptions) ([]byte, error) {
+	data, ok := b.files[name]
+	if !ok {
+		return nil, fmt.Errorf("backup has no backup key for this environment")
+	}
+
+	var backupKey types.WrappedKey
+	if err := json.Unmarshal(data, &backupKey); err != nil {
+		return nil, fmt.Errorf("failed to parse backup key: %w", err)
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(backupKey.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode backup key: %w", err)
+	}
+
+	masterKey, err := crypto.UnwrapKey(opts.BackupKey, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.ZeroBytes(masterKey)
+
+	publicKey, err := crypto.DecodePublicKeyPEM([]byte(opts.Machine.PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key for %s: %w", opts.Machine.ID, err)
+	}
+
+	rewrapped, err := crypto.WrapKey(publicKey, masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(&types.WrappedKey{
+		MachineID:            opts.Machine.ID,
+		PublicKeyFingerprint: opts.Machine.Fingerprint,
+		WrappedKey:           base64.StdEncoding.EncodeToString(rewrapped),
+		GrantedBy:            BackupKeyID,
+		GrantedAt:            time.Now(),
+	}, "", "  ")
+}
+
+// filesUnder returns the sorted archive paths directly inside dir
+func (b *Backup) filesUnder(dir string) []string {
+	var names []string
+	for name := range b.files {
+		if path.Dir(name) == dir {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hasFile reports whether the archive contains a file
+func (b *Backup) hasFile(name string) bool {
+	_, ok := b.files[name]
+	return ok
+}
+
+// wrapForBackupKey wraps the master key of an environment for the backup public key
+func wrapForBackupKey(paths *Paths, environment string, backupKey *rsa.PublicKey, fingerprint, grantedBy string) ([]byte, error) {
+	masterKey, err := UnwrapMasterKey(paths, environment)
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.ZeroBytes(masterKey)
+
+	wrapped, err := crypto.WrapKey(backupKey, masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(&types.WrappedKey{
+		MachineID:            BackupKeyID,
+		PublicKeyFingerprint: fingerprint,
+		WrappedKey:           base64.StdEncoding.EncodeToString(wrapped),
+		GrantedBy:            grantedBy,
+		GrantedAt:            time.Now(),
+	}, "", "  ")
+}
+
+// addBackupDir adds the files of a vault directory to the archive under archiveDir
+func addBackupDir(storage Storage, files map[string][]byte, dir, archiveDir string) error {
+	entries, err := storage.ListFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		data, err := storage.ReadFile(entry)
+		if err != nil {
+			return err
+		}
+		files[path.Join(archiveDir, filepath.Base(entry))] = data
+	}
+
+	return nil
+}
+
+// writeBackupArchive writes the manifest followed by all files as a gzipped tar
+func writeBackupArchive(w io.Writer, manifest *BackupManifest, files map[string][]byte) error {
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal backup manifest: %w", err)
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	writeEntry := func(name string, data []byte) error {
+		header := &tar.Header{
+			Name:    name,
+			Mode:    int64(FilePerm),
+			Size:    int64(len(data)),
+			ModTime: manifest.CreatedAt,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	if err := writeEntry(backupManifestFile, manifestData); err != nil {
+		return fmt.Errorf("failed to write backup archive: %w", err)
+	}
+	for _, name := range names {
+		if err := writeEntry(name, files[name]); err != nil {
+			return fmt.Errorf("failed to write backup archive: %w", err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write backup archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write backup archive: %w", err)
+	}
+
+	return nil
+}
+
+// checksum returns the hex SHA-256 of data
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
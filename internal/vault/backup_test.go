@@ -0,0 +1,146 @@
+package vault
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iluxav/nvolt/internal/crypto"
+)
+
+func TestBackupRestoreWithBackupKey(t *testing.T) {
+	t.Setenv("NVOLT_CONFIG", t.TempDir())
+	owner, err := InitializeMachine("owner")
+	if err != nil {
+		t.Fatalf("Failed to initialize machine: %v", err)
+	}
+
+	sourcePath := filepath.Join(t.TempDir(), NvoltDir)
+	if err := InitializeVaultDirectory(sourcePath); err != nil {
+		t.Fatalf("Failed to initialize vault: %v", err)
+	}
+	source := GetVaultPaths(sourcePath, "")
+	if err := AddMachineToVault(source, owner); err != nil {
+		t.Fatalf("Failed to add machine: %v", err)
+	}
+	pushTestSecrets(t, source, "default", owner.ID, map[string]string{"A": "1", "B": "2"})
+	pushTestSecrets(t, source, "production", owner.ID, map[string]string{"C": "3"})
+
+	backupKey, err := crypto.GenerateRSAKeypair()
+	if err != nil {
+		t.Fatalf("Failed to generate backup key: %v", err)
+	}
+
+	var archive bytes.Buffer
+	manifest, err := CreateBackup(&archive, sourcePath, BackupOptions{
+		Environments: []string{"default"},
+		BackupKey:    &backupKey.PublicKey,
+		CreatedBy:    owner.ID,
+	})
+	if err != nil {
+		t.Fatalf("CreateBackup failed: %v", err)
+	}
+	if len(manifest.Projects) != 1 || len(manifest.Projects[0].Environments) != 1 {
+		t.Fatalf("Expected only the default environment, got %+v", manifest.Projects)
+	}
+
+	backup, err := ReadBackup(bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadBackup failed: %v", err)
+	}
+
+	// A new machine that was never granted access restores with the backup key
+	t.Setenv("NVOLT_CONFIG", t.TempDir())
+	newMachine, err := InitializeMachine("new")
+	if err != nil {
+		t.Fatalf("Failed to initialize machine: %v", err)
+	}
+
+	targetPath := filepath.Join(t.TempDir(), NvoltDir)
+	if err := InitializeVaultDirectory(targetPath); err != nil {
+		t.Fatalf("Failed to initialize vault: %v", err)
+	}
+
+	result, err := RestoreBackup(backup, targetPath, RestoreOptions{BackupKey: backupKey, Machine: newMachine})
+	if err != nil {
+		t.Fatalf("RestoreBackup failed: %v", err)
+	}
+	if result.Secrets != 2 || len(result.Granted) != 1 || len(result.MachinesAdded) != 2 {
+		t.Errorf("Unexpected restore result: %+v", result)
+	}
+
+	target := GetVaultPaths(targetPath, "")
+	if _, err := VerifyEnvironmentDecrypts(target, "default"); err != nil {
+		t.Errorf("Restored environment should decrypt on the new machine: %v", err)
+	}
+	if EnvironmentExists(target, "production") {
+		t.Error("Environments outside the backup should not be restored")
+	}
+
+	// Restoring again conflicts with the restored environment
+	if _, err := RestoreBackup(backup, targetPath, RestoreOptions{}); err == nil {
+		t.Error("Expected error when environments already exist in target")
+	}
+}
+
+func TestReadBackupDetectsCorruption(t *testing.T) {
+	paths, _ := newTestVault(t)
+	pushTestSecrets(t, paths, "default", "m-test", map[string]string{"A": "1"})
+
+	var archive bytes.Buffer
+	if _, err := CreateBackup(&archive, paths.Root, BackupOptions{}); err != nil {
+		t.Fatalf("CreateBackup failed: %v", err)
+	}
+
+	backup, err := ReadBackup(bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadBackup failed: %v", err)
+	}
+
+	// Rewrite the archive with a modified secret but the original manifest
+	name := backupProjectDir("") + "/secrets/default/A.enc.json"
+	backup.files[name] = append(backup.files[name], ' ')
+
+	var tampered bytes.Buffer
+	if err := writeBackupArchive(&tampered, backup.Manifest, backup.files); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+
+	_, err = ReadBackup(&tampered)
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Expected checksum mismatch, got %v", err)
+	}
+}
+
+func TestRestoreLocalBackupIntoGlobalVault(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("NVOLT_CONFIG", home)
+
+	paths, _ := newTestVault(t)
+	pushTestSecrets(t, paths, "default", "m-test", map[string]string{"A": "1"})
+
+	var archive bytes.Buffer
+	if _, err := CreateBackup(&archive, paths.Root, BackupOptions{}); err != nil {
+		t.Fatalf("CreateBackup failed: %v", err)
+	}
+	backup, err := ReadBackup(&archive)
+	if err != nil {
+		t.Fatalf("ReadBackup failed: %v", err)
+	}
+
+	globalPath := filepath.Join(home, OrgsDir, "acme", "secrets")
+	if _, err := RestoreBackup(backup, globalPath, RestoreOptions{}); err == nil {
+		t.Error("Expected error when no target project is given for a local backup")
+	}
+
+	if _, err := RestoreBackup(backup, globalPath, RestoreOptions{Project: "app"}); err != nil {
+		t.Fatalf("RestoreBackup failed: %v", err)
+	}
+	if !FileExists(filepath.Join(globalPath, "app", SecretsDir, "default", "A.enc.json")) {
+		t.Error("Secret should be restored under the target project")
+	}
+	if !FileExists(filepath.Join(globalPath, MachinesDir, "m-test.json")) {
+		t.Error("Machine should be restored at the repository root")
+	}
+}
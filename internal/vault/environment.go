@@ -0,0 +1,203 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/iluxav/nvolt/internal/validation"
+)
+
+// ListEnvironments lists all environments in the vault
+// An environment exists if it has a secrets or a wrapped keys directory
+func ListEnvironments(paths *Paths) ([]string, error) {
+	seen := make(map[string]bool)
+
+	for _, dir := range []string{paths.Secrets, paths.WrappedKeys} {
+		envDirs, err := paths.Storage().ListDirs(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, envDir := range envDirs {
+			seen[GetDirName(envDir)] = true
+		}
+	}
+
+	environments := make([]string, 0, len(seen))
+	for env := range seen {
+		environments = append(environments, env)
+	}
+	sort.Strings(environments)
+
+	return environments, nil
+}
+
+// ListProjects lists the projects of a vault. Local vaults have a single
+// unnamed project (""); in global mode a project is any top-level directory
+// with secrets or wrapped keys.
+func ListProjects(vaultPath string) ([]string, error) {
+	if !IsGlobalMode(vaultPath) {
+		return []string{""}, nil
+	}
+
+	storage, err := StorageFor(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs, err := storage.ListDirs(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var projects []string
+	for _, dir := range dirs {
+		name := GetDirName(dir)
+		if strings.HasPrefix(name, ".") || name == MachinesDir {
+			continue
+		}
+		if storage.Exists(filepath.Join(dir, SecretsDir)) || storage.Exists(filepath.Join(dir, WrappedKeysDir)) {
+			projects = append(projects, name)
+		}
+	}
+	sort.Strings(projects)
+
+	return projects, nil
+}
+
+// EnvironmentExists checks if an environment has secrets or wrapped keys
+func EnvironmentExists(paths *Paths, environment string) bool {
+	storage := paths.Storage()
+	return storage.Exists(paths.GetSecretsPath(environment)) || storage.Exists(paths.GetWrappedKeysEnvPath(environment))
+}
+
+// ListEnvironmentMachines returns the IDs of machines with a wrapped key for an environment
+func ListEnvironmentMachines(paths *Paths, environment string) ([]string, error) {
+	files, err := paths.Storage().ListFiles(paths.GetWrappedKeysEnvPath(environment))
+	if err != nil {
+		return nil, err
+	}
+
+	machineIDs := make([]string, 0, len(files))
+	for _, file := range files {
+		name := filepath.Base(file)
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		machineIDs = append(machineIDs, strings.TrimSuffix(name, ".json"))
+	}
+	sort.Strings(machineIDs)
+
+	return machineIDs, nil
+}
+
+// CopyEnvironment copies all secrets from source to target, re-encrypting them
+// with targetKey, and grants targetKey to every machine that has access to source.
+// Returns the IDs of the machines granted access to target.
+func CopyEnvironment(paths *Paths, source, target string, sourceKey, targetKey []byte, grantedBy string) ([]string, error) {
+	if err := validation.ValidateEnvironmentNames(source, target); err != nil {
+		return nil, err
+	}
+	if EnvironmentExists(paths, target) {
+		return nil, fmt.Errorf("environment '%s' already exists", target)
+	}
+
+	secretFiles, err := paths.Storage().ListFiles(paths.GetSecretsPath(source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+
+	// Re-encrypt every secret under the new master key
+	for _, secretFile := range secretFiles {
+		key := GetSecretKeyFromFilename(secretFile)
+
+		encrypted, err := LoadEncryptedSecret(paths, source, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load secret %s: %w", key, err)
+		}
+
+		plaintext, err := DecryptSecret(sourceKey, encrypted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt secret %s: %w", key, err)
+		}
+
+		reencrypted, err := EncryptSecret(targetKey, plaintext)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt secret %s: %w", key, err)
+		}
+
+		if err := SaveEncryptedSecret(paths, target, key, reencrypted); err != nil {
+			return nil, fmt.Errorf("failed to save secret %s: %w", key, err)
+		}
+	}
+
+	// Grant the new master key to the same machines
+	machineIDs, err := ListEnvironmentMachines(paths, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines with access: %w", err)
+	}
+
+	var granted []string
+	for _, machineID := range machineIDs {
+		if _, err := GrantMachineAccess(paths, target, machineID, targetKey, grantedBy); err != nil {
+			// Orphaned wrapped keys (machine removed from vault) are skipped
+			if errors.Is(err, ErrMachineNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		granted = append(granted, machineID)
+	}
+
+	return granted, nil
+}
+
+// RenameEnvironment moves the secrets and wrapped keys of an environment
+func RenameEnvironment(paths *Paths, source, target string) error {
+	if err := validation.ValidateEnvironmentNames(source, target); err != nil {
+		return err
+	}
+	if !EnvironmentExists(paths, source) {
+		return fmt.Errorf("environment '%s' not found", source)
+	}
+	if EnvironmentExists(paths, target) {
+		return fmt.Errorf("environment '%s' already exists", target)
+	}
+
+	moves := [][2]string{
+		{paths.GetSecretsPath(source), paths.GetSecretsPath(target)},
+		{paths.GetWrappedKeysEnvPath(source), paths.GetWrappedKeysEnvPath(target)},
+	}
+
+	return paths.Storage().Batch(func(storage Storage) error {
+		for _, move := range moves {
+			if _, err := copyDirFiles(storage, storage, move[0], move[1]); err != nil {
+				return fmt.Errorf("failed to move %s: %w", move[0], err)
+			}
+			if err := storage.Remove(move[0]); err != nil {
+				return fmt.Errorf("failed to move %s: %w", move[0], err)
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteEnvironment removes the secrets and wrapped keys of an environment
+func DeleteEnvironment(paths *Paths, environment string) error {
+	if err := validation.ValidateEnvironmentName(environment); err != nil {
+		return err
+	}
+	if !EnvironmentExists(paths, environment) {
+		return fmt.Errorf("environment '%s' not found", environment)
+	}
+
+	for _, dir := range []string{paths.GetSecretsPath(environment), paths.GetWrappedKeysEnvPath(environment)} {
+		if err := paths.remove(dir); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", dir, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package vault
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iluxav/nvolt/internal/crypto"
+	"github.com/iluxav/nvolt/pkg/types"
+)
+
+// newTestMachine registers a machine with a freshly generated keypair in the vault
+func newTestMachine(t *testing.T, paths *Paths, id string) *crypto.RSAPrivateKey {
+	t.Helper()
+
+	privateKey, err := crypto.GenerateRSAKeypair()
+	if err != nil {
+		t.Fatalf("Failed to generate keypair: %v", err)
+	}
+
+	publicKeyPEM, err := crypto.EncodePublicKeyPEM(&privateKey.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to encode public key: %v", err)
+	}
+
+	machine := &types.MachineInfo{
+		ID:          id,
+		PublicKey:   string(publicKeyPEM),
+		Fingerprint: "SHA256:" + id,
+		Hostname:    id,
+		CreatedAt:   time.Now(),
+	}
+	if err := AddMachineToVault(paths, machine); err != nil {
+		t.Fatalf("Failed to add machine: %v", err)
+	}
+
+	return privateKey
+}
+
+// newTestVault creates a local vault with a single machine
+func newTestVault(t *testing.T) (*Paths, *crypto.RSAPrivateKey) {
+	t.Helper()
+
+	vaultPath := filepath.Join(t.TempDir(), NvoltDir)
+	if err := InitializeVaultDirectory(vaultPath); err != nil {
+		t.Fatalf("Failed to initialize vault: %v", err)
+	}
+
+	paths := GetVaultPaths(vaultPath, "")
+	return paths, newTestMachine(t, paths, "m-test")
+}
+
+// pushTestSecrets encrypts secrets into an environment and grants the key to machineID
+func pushTestSecrets(t *testing.T, paths *Paths, environment, machineID string, secrets map[string]string) []byte {
+	t.Helper()
+
+	masterKey, err := crypto.GenerateAESKey()
+	if err != nil {
+		t.Fatalf("Failed to generate master key: %v", err)
+	}
+
+	for key, value := range secrets {
+		encrypted, err := EncryptSecret(masterKey, value)
+		if err != nil {
+			t.Fatalf("Failed to encrypt secret: %v", err)
+		}
+		if err := SaveEncryptedSecret(paths, environment, key, encrypted); err != nil {
+			t.Fatalf("Failed to save secret: %v", err)
+		}
+	}
+
+	if _, err := GrantMachineAccess(paths, environment, machineID, masterKey, machineID); err != nil {
+		t.Fatalf("Failed to grant access: %v", err)
+	}
+
+	return masterKey
+}
+
+func TestListEnvironments(t *testing.T) {
+	paths, _ := newTestVault(t)
+	pushTestSecrets(t, paths, "production", "m-test", map[string]string{"A": "1"})
+	pushTestSecrets(t, paths, "default", "m-test", map[string]string{"B": "2"})
+
+	envs, err := ListEnvironments(paths)
+	if err != nil {
+		t.Fatalf("ListEnvironments failed: %v", err)
+	}
+
+	if len(envs) != 2 || envs[0] != "default" || envs[1] != "production" {
+		t.Errorf("Expected [default production], got %v", envs)
+	}
+}
+
+func TestCopyEnvironment(t *testing.T) {
+	paths, _ := newTestVault(t)
+	newTestMachine(t, paths, "m-other")
+	newTestMachine(t, paths, "m-noaccess")
+	newTestMachine(t, paths, "m-removed")
+
+	sourceKey := pushTestSecrets(t, paths, "staging", "m-test", map[string]string{"A": "1", "B": "2"})
+	for _, machineID := range []string{"m-other", "m-removed"} {
+		if _, err := GrantMachineAccess(paths, "staging", machineID, sourceKey, "m-test"); err != nil {
+			t.Fatalf("Failed to grant access: %v", err)
+		}
+	}
+
+	// The wrapped key of a removed machine is left behind
+	if err := DeleteFile(paths.GetMachineInfoPath("m-removed")); err != nil {
+		t.Fatalf("Failed to remove machine: %v", err)
+	}
+	if _, err := GrantMachineAccess(paths, "staging", "m-removed", sourceKey, "m-test"); !errors.Is(err, ErrMachineNotFound) {
+		t.Fatalf("Expected ErrMachineNotFound, got %v", err)
+	}
+
+	targetKey, err := crypto.GenerateAESKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	granted, err := CopyEnvironment(paths, "staging", "production", sourceKey, targetKey, "m-test")
+	if err != nil {
+		t.Fatalf("CopyEnvironment failed: %v", err)
+	}
+
+	if len(granted) != 2 {
+		t.Errorf("Expected 2 machines granted, got %v", granted)
+	}
+	if FileExists(paths.GetWrappedKeyPath("production", "m-noaccess")) {
+		t.Error("Machine without access to source should not be granted access to target")
+	}
+
+	encrypted, err := LoadEncryptedSecret(paths, "production", "B")
+	if err != nil {
+		t.Fatalf("Failed to load copied secret: %v", err)
+	}
+	value, err := DecryptSecret(targetKey, encrypted)
+	if err != nil {
+		t.Fatalf("Copied secret should decrypt with target key: %v", err)
+	}
+	if value != "2" {
+		t.Errorf("Expected 2, got %s", value)
+	}
+	if _, err := DecryptSecret(sourceKey, encrypted); err == nil {
+		t.Error("Copied secret should not decrypt with source key")
+	}
+
+	// Copying onto an existing environment fails
+	if _, err := CopyEnvironment(paths, "staging", "production", sourceKey, targetKey, "m-test"); err == nil {
+		t.Error("Expected error when target environment exists")
+	}
+}
+
+func TestRenameEnvironment(t *testing.T) {
+	paths, _ := newTestVault(t)
+	pushTestSecrets(t, paths, "stage", "m-test", map[string]string{"A": "1"})
+
+	if err := RenameEnvironment(paths, "stage", "staging"); err != nil {
+		t.Fatalf("RenameEnvironment failed: %v", err)
+	}
+
+	if EnvironmentExists(paths, "stage") {
+		t.Error("Source environment should no longer exist")
+	}
+	if !FileExists(paths.GetSecretFilePath("staging", "A")) {
+		t.Error("Secret should have been moved")
+	}
+	if !FileExists(paths.GetWrappedKeyPath("staging", "m-test")) {
+		t.Error("Wrapped key should have been moved")
+	}
+
+	if err := RenameEnvironment(paths, "missing", "other"); err == nil {
+		t.Error("Expected error for missing source environment")
+	}
+}
+
+func TestDeleteEnvironment(t *testing.T) {
+	paths, _ := newTestVault(t)
+	pushTestSecrets(t, paths, "old", "m-test", map[string]string{"A": "1"})
+
+	if err := DeleteEnvironment(paths, "old"); err != nil {
+		t.Fatalf("DeleteEnvironment failed: %v", err)
+	}
+
+	if EnvironmentExists(paths, "old") {
+		t.Error("Environment should have been deleted")
+	}
+
+	if err := DeleteEnvironment(paths, "old"); err == nil {
+		t.Error("Expected error when deleting missing environment")
+	}
+}
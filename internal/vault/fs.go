@@ -81,11 +81,28 @@ func WriteFileAtomic(path string, data []byte, perm fs.FileMode) error {
 		return err
 	}
 
-	// Write to temporary file with restrictive permissions
-	tmpPath := path + ".tmp"
-	if err := os.WriteFile(tmpPath, data, perm); err != nil {
+	// Write to a uniquely named temporary file so concurrent writers never
+	// share a temp file (CreateTemp uses restrictive permissions)
+	tmpFile, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		SecureDeleteFile(tmpPath)
 		return fmt.Errorf("failed to write temporary file: %w", err)
 	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		SecureDeleteFile(tmpPath)
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		SecureDeleteFile(tmpPath)
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
 
 	// Ensure correct permissions on temp file
 	if err := os.Chmod(tmpPath, perm); err != nil {
@@ -229,7 +246,7 @@ func ListDirs(dir string) ([]string, error) {
 // EnsureSecretsDir creates a directory for an environment's secrets
 func EnsureSecretsDir(paths *Paths, environment string) error {
 	secretsPath := paths.GetSecretsPath(environment)
-	return ensureDir(secretsPath, DirPerm)
+	return paths.ensureDir(secretsPath)
 }
 
 // ValidateVaultStructure verifies that all required vault directories exist
@@ -244,6 +261,11 @@ func ValidateVaultStructure(vaultPath string) error {
 		paths.Machines,
 	}
 
+	// Other storages have implicit directories
+	if _, ok := paths.Storage().(*FSStorage); !ok {
+		requiredDirs = requiredDirs[:1]
+	}
+
 	for _, dir := range requiredDirs {
 		info, err := os.Stat(dir)
 		if os.IsNotExist(err) {
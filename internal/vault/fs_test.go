@@ -313,3 +313,26 @@ func TestIsVaultInitialized(t *testing.T) {
 		t.Error("Vault should be initialized")
 	}
 }
+
+func TestWriteFileAtomicConcurrent(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.txt")
+
+	done := make(chan error)
+	for i := 0; i < 10; i++ {
+		go func() {
+			done <- WriteFileAtomic(testFile, []byte("test data"), FilePerm)
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		if err := <-done; err != nil {
+			t.Errorf("Concurrent write failed: %v", err)
+		}
+	}
+
+	// Verify no temp files remain
+	matches, _ := filepath.Glob(filepath.Join(tmpDir, "*.tmp"))
+	if len(matches) > 0 {
+		t.Errorf("Temporary files should have been removed: %v", matches)
+	}
+}
@@ -0,0 +1,204 @@
+package vault
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ProjectLoader loads the decrypted secrets of another project/environment.
+// It is used to resolve cross-project references of the form ${project:env:KEY}.
+type ProjectLoader func(project, environment string) (map[string]string, error)
+
+// Fallback locates a ${KEY} reference that is not in the secret set being
+// resolved. It returns the scope of the key, the secrets of that scope and
+// the key's name within them.
+type Fallback func(key string) (scope string, secrets map[string]string, name string, ok bool)
+
+// Interpolator resolves ${KEY} and ${project:env:KEY} references in secret values
+type Interpolator struct {
+	load     ProjectLoader
+	fallback Fallback
+	external map[string]map[string]string // "project:env" -> raw secrets
+	resolved map[string]string            // node id -> resolved value
+}
+
+// NewInterpolator creates an interpolator. load may be nil, in which case
+// cross-project references are reported as not accessible.
+func NewInterpolator(load ProjectLoader) *Interpolator {
+	return &Interpolator{
+		load:     load,
+		external: make(map[string]map[string]string),
+		resolved: make(map[string]string),
+	}
+}
+
+// SetFallback sets where ${KEY} references missing from the secrets being
+// resolved are looked up, e.g. other composed projects
+func (in *Interpolator) SetFallback(fallback Fallback) {
+	in.fallback = fallback
+}
+
+// Interpolate returns a copy of secrets with all references resolved.
+// Plain ${KEY} references are resolved against secrets itself, ${project:env:KEY}
+// references are resolved against the referenced project. Use $${ to produce
+// a literal "${".
+func Interpolate(secrets map[string]string, load ProjectLoader) (map[string]string, error) {
+	return NewInterpolator(load).Resolve(secrets)
+}
+
+// Resolve resolves every value in secrets
+func (in *Interpolator) Resolve(secrets map[string]string) (map[string]string, error) {
+	keys := make([]string, 0, len(secrets))
+	for key := range secrets {
+		keys = append(keys, key)
+	}
+	return in.ResolveKeys(secrets, keys, nil)
+}
+
+// ResolveKeys resolves the values of keys only. References may point at any
+// key of secrets. A key for which optional returns true keeps its raw value
+// if its references cannot be resolved.
+func (in *Interpolator) ResolveKeys(secrets map[string]string, keys []string, optional func(key string) bool) (map[string]string, error) {
+	// Report the same error on every run
+	keys = slices.Clone(keys)
+	slices.Sort(keys)
+
+	result := make(map[string]string, len(keys))
+	for _, key := range keys {
+		value, err := in.resolveKey("", secrets, key, nil)
+		if err != nil {
+			if optional == nil || !optional(key) {
+				return nil, err
+			}
+			value = secrets[key]
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// resolveKey resolves a single key within a scope ("" for the main secret set,
+// "project:env" for external projects). stack holds the node ids currently
+// being resolved and is used for cycle detection.
+func (in *Interpolator) resolveKey(scope string, secrets map[string]string, key string, stack []string) (string, error) {
+	id := nodeID(scope, key)
+	if value, ok := in.resolved[id]; ok {
+		return value, nil
+	}
+
+	for i, s := range stack {
+		if s == id {
+			cycle := append(append([]string{}, stack[i:]...), id)
+			return "", fmt.Errorf("circular reference detected: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	raw, ok := secrets[key]
+	if !ok {
+		return "", fmt.Errorf("key '%s' not found", key)
+	}
+
+	stack = append(stack, id)
+	value, err := in.expand(scope, secrets, key, raw, stack)
+	if err != nil {
+		return "", err
+	}
+
+	in.resolved[id] = value
+	return value, nil
+}
+
+// expand replaces every reference in raw
+func (in *Interpolator) expand(scope string, secrets map[string]string, key, raw string, stack []string) (string, error) {
+	if !strings.Contains(raw, "${") {
+		return raw, nil
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(raw); i++ {
+		// Escaped reference: $${ -> ${
+		if strings.HasPrefix(raw[i:], "$${") {
+			builder.WriteString("${")
+			i += 2
+			continue
+		}
+
+		if !strings.HasPrefix(raw[i:], "${") {
+			builder.WriteByte(raw[i])
+			continue
+		}
+
+		end := strings.IndexByte(raw[i+2:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated reference in '%s'", nodeID(scope, key))
+		}
+		ref := raw[i+2 : i+2+end]
+
+		value, err := in.resolveRef(scope, secrets, ref, stack)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve ${%s} in '%s': %w", ref, nodeID(scope, key), err)
+		}
+		builder.WriteString(value)
+		i += 2 + end
+	}
+
+	return builder.String(), nil
+}
+
+// resolveRef resolves the contents of a single ${...} reference
+func (in *Interpolator) resolveRef(scope string, secrets map[string]string, ref string, stack []string) (string, error) {
+	parts := strings.Split(ref, ":")
+	for _, part := range parts {
+		if strings.TrimSpace(part) == "" {
+			return "", fmt.Errorf("invalid reference: expected ${KEY} or ${project:env:KEY}")
+		}
+	}
+
+	switch len(parts) {
+	case 1:
+		if _, ok := secrets[ref]; !ok && in.fallback != nil && in.external[scope] == nil {
+			if fallbackScope, fallbackSecrets, name, ok := in.fallback(ref); ok {
+				return in.resolveKey(fallbackScope, fallbackSecrets, name, stack)
+			}
+		}
+		return in.resolveKey(scope, secrets, ref, stack)
+	case 3:
+		project, environment, key := parts[0], parts[1], parts[2]
+		external, err := in.loadExternal(project, environment)
+		if err != nil {
+			return "", err
+		}
+		return in.resolveKey(project+":"+environment, external, key, stack)
+	default:
+		return "", fmt.Errorf("invalid reference: expected ${KEY} or ${project:env:KEY}")
+	}
+}
+
+// loadExternal loads (and caches) the raw secrets of another project
+func (in *Interpolator) loadExternal(project, environment string) (map[string]string, error) {
+	scope := project + ":" + environment
+	if secrets, ok := in.external[scope]; ok {
+		return secrets, nil
+	}
+
+	if in.load == nil {
+		return nil, fmt.Errorf("project '%s' environment '%s' is not accessible: cross-project references are not available here", project, environment)
+	}
+
+	secrets, err := in.load(project, environment)
+	if err != nil {
+		return nil, fmt.Errorf("project '%s' environment '%s' is not accessible from this machine: %w", project, environment, err)
+	}
+
+	in.external[scope] = secrets
+	return secrets, nil
+}
+
+// nodeID returns a human-readable identifier for a key within a scope
+func nodeID(scope, key string) string {
+	if scope == "" {
+		return key
+	}
+	return scope + ":" + key
+}
@@ -0,0 +1,127 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// LockFile is the name of the advisory lock file
+	LockFile = "nvolt.lock"
+
+	// lockPollInterval is how often a waiting process retries the lock
+	lockPollInterval = 100 * time.Millisecond
+)
+
+// errLockBusy is returned by the platform lock implementation when another
+// process holds the lock
+var errLockBusy = errors.New("lock is held by another process")
+
+// LockedError is returned when the vault is locked by another process
+type LockedError struct {
+	PID  int // 0 if unknown
+	Path string
+}
+
+func (e *LockedError) Error() string {
+	if e.PID > 0 {
+		return fmt.Sprintf("vault is locked by PID %d (%s). Retry later or use --wait", e.PID, e.Path)
+	}
+	return fmt.Sprintf("vault is locked by another process (%s). Retry later or use --wait", e.Path)
+}
+
+// VaultLock is an advisory, cross-process lock on a vault
+type VaultLock struct {
+	file *os.File
+	path string
+}
+
+// GetLockPath returns the lock file path for a vault
+func GetLockPath(vaultPath string) string {
+	return filepath.Join(getStateDir(vaultPath), LockFile)
+}
+
+// getStateDir returns the directory for nvolt's working state (locks and
+// transactions). It is inside .git when the vault is in a Git repository so
+// nothing there is ever committed, and the vault directory otherwise.
+func getStateDir(vaultPath string) string {
+	gitDir := filepath.Join(GetRepoRootFromVault(vaultPath), ".git")
+	if info, err := os.Stat(gitDir); err == nil && info.IsDir() {
+		return gitDir
+	}
+	return vaultPath
+}
+
+// LockVault acquires the vault lock. If the vault is locked by another
+// process, it retries until wait has elapsed (wait <= 0 means don't wait)
+// and then returns a *LockedError.
+func LockVault(vaultPath string, wait time.Duration) (*VaultLock, error) {
+	path := GetLockPath(vaultPath)
+	if err := ensureDir(filepath.Dir(path), DirPerm); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, PrivateKeyPerm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		err := lockFile(file)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLockBusy) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock vault: %w", err)
+		}
+		if !time.Now().Before(deadline) {
+			file.Close()
+			return nil, &LockedError{PID: readLockPID(path), Path: path}
+		}
+		time.Sleep(lockPollInterval)
+	}
+
+	// Record the owner so other processes can report who holds the lock
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
+		file.Sync()
+	}
+
+	return &VaultLock{file: file, path: path}, nil
+}
+
+// Unlock releases the vault lock
+func (l *VaultLock) Unlock() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+
+	l.file.Truncate(0)
+	err := unlockFile(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	l.file = nil
+
+	return err
+}
+
+// readLockPID reads the PID of the lock owner (0 if unknown)
+func readLockPID(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
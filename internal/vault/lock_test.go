@@ -0,0 +1,71 @@
+package vault
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockVault(t *testing.T) {
+	vaultPath := filepath.Join(t.TempDir(), NvoltDir)
+
+	lock, err := LockVault(vaultPath, 0)
+	if err != nil {
+		t.Fatalf("Failed to lock vault: %v", err)
+	}
+
+	// A second lock attempt fails and reports the owner
+	_, err = LockVault(vaultPath, 0)
+	var lockedErr *LockedError
+	if !errors.As(err, &lockedErr) {
+		t.Fatalf("Expected LockedError, got %v", err)
+	}
+	if lockedErr.PID != os.Getpid() {
+		t.Errorf("Expected lock owner PID %d, got %d", os.Getpid(), lockedErr.PID)
+	}
+
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Failed to unlock vault: %v", err)
+	}
+
+	// After unlocking the vault can be locked again
+	lock, err = LockVault(vaultPath, 0)
+	if err != nil {
+		t.Fatalf("Failed to re-lock vault: %v", err)
+	}
+	lock.Unlock()
+}
+
+func TestLockVaultWait(t *testing.T) {
+	vaultPath := filepath.Join(t.TempDir(), NvoltDir)
+
+	lock, err := LockVault(vaultPath, 0)
+	if err != nil {
+		t.Fatalf("Failed to lock vault: %v", err)
+	}
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		lock.Unlock()
+	}()
+
+	second, err := LockVault(vaultPath, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Expected to acquire lock after waiting: %v", err)
+	}
+	second.Unlock()
+}
+
+func TestGetLockPathInGitRepo(t *testing.T) {
+	repoRoot := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repoRoot, ".git"), DirPerm); err != nil {
+		t.Fatalf("Failed to create .git: %v", err)
+	}
+
+	vaultPath := filepath.Join(repoRoot, NvoltDir)
+	if got, want := GetLockPath(vaultPath), filepath.Join(repoRoot, ".git", LockFile); got != want {
+		t.Errorf("Expected lock inside .git (%s), got %s", want, got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/iluxav/nvolt/internal/crypto"
+	"github.com/iluxav/nvolt/internal/validation"
 	"github.com/iluxav/nvolt/pkg/types"
 )
 
@@ -105,6 +106,21 @@ func LoadMachineInfoFromFile(path string) (*types.MachineInfo, error) {
 		return nil, fmt.Errorf("failed to read machine info: %w", err)
 	}
 
+	return parseMachineInfo(data)
+}
+
+// loadMachineInfo loads machine info from a vault storage
+func loadMachineInfo(storage Storage, path string) (*types.MachineInfo, error) {
+	data, err := storage.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read machine info: %w", err)
+	}
+
+	return parseMachineInfo(data)
+}
+
+// parseMachineInfo decodes a machine info file
+func parseMachineInfo(data []byte) (*types.MachineInfo, error) {
 	var machineInfo types.MachineInfo
 	if err := json.Unmarshal(data, &machineInfo); err != nil {
 		return nil, fmt.Errorf("failed to parse machine info: %w", err)
@@ -192,37 +208,54 @@ func GenerateMachineID(customName, hostname, fingerprint string) string {
 // Uses unified paths - works identically in both local and global modes
 func AddMachineToVault(paths *Paths, machineInfo *types.MachineInfo) error {
 	// Ensure machines directory exists
-	if err := ensureDir(paths.Machines, DirPerm); err != nil {
+	if err := paths.ensureDir(paths.Machines); err != nil {
 		return fmt.Errorf("failed to create machines directory: %w", err)
 	}
 
+	if err := validation.ValidateMachineID(machineInfo.ID); err != nil {
+		return err
+	}
+
 	machinePath := paths.GetMachineInfoPath(machineInfo.ID)
 
 	// Check if machine already exists
-	if FileExists(machinePath) {
+	if paths.Storage().Exists(machinePath) {
 		return fmt.Errorf("machine %s already exists in vault", machineInfo.ID)
 	}
 
-	return SaveMachineInfo(machinePath, machineInfo)
+	data, err := json.MarshalIndent(machineInfo, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal machine info: %w", err)
+	}
+
+	if err := paths.writeFile(machinePath, data, FilePerm); err != nil {
+		return fmt.Errorf("failed to write machine info: %w", err)
+	}
+
+	return nil
 }
 
 // RemoveMachineFromVault removes a machine from the vault
 // Uses unified paths - works identically in both local and global modes
 func RemoveMachineFromVault(paths *Paths, machineID string) error {
+	if err := validation.ValidateMachineID(machineID); err != nil {
+		return err
+	}
+
 	machinePath := paths.GetMachineInfoPath(machineID)
 
 	// Remove machine info
-	if err := DeleteFile(machinePath); err != nil {
+	if err := paths.remove(machinePath); err != nil {
 		return fmt.Errorf("failed to remove machine info: %w", err)
 	}
 
 	// Remove wrapped keys from all environments
-	envDirs, err := ListDirs(paths.Secrets)
+	envDirs, err := paths.Storage().ListDirs(paths.Secrets)
 	if err == nil {
 		for _, envDir := range envDirs {
 			envName := GetDirName(envDir)
 			wrappedKeyPath := paths.GetWrappedKeyPath(envName, machineID)
-			if err := DeleteFile(wrappedKeyPath); err != nil {
+			if err := paths.remove(wrappedKeyPath); err != nil {
 				// Wrapped key might not exist, that's okay
 				if !os.IsNotExist(err) {
 					return fmt.Errorf("failed to remove wrapped key for environment '%s': %w", envName, err)
@@ -237,18 +270,22 @@ func RemoveMachineFromVault(paths *Paths, machineID string) error {
 // ListMachines lists all machines in the vault
 // Uses unified paths - works identically in both local and global modes
 func ListMachines(paths *Paths) ([]*types.MachineInfo, error) {
-	files, err := ListFiles(paths.Machines)
+	files, err := paths.Storage().ListFiles(paths.Machines)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list machines: %w", err)
 	}
 
 	var machines []*types.MachineInfo
 	for _, file := range files {
-		machineInfo, err := LoadMachineInfoFromFile(file)
+		machineInfo, err := loadMachineInfo(paths.Storage(), file)
 		if err != nil {
 			// Skip invalid files
 			continue
 		}
+		if validation.ValidateMachineID(machineInfo.ID) != nil {
+			// Never derive paths from unsafe IDs; 'vault verify' reports them
+			continue
+		}
 		machines = append(machines, machineInfo)
 	}
 
@@ -0,0 +1,169 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/iluxav/nvolt/internal/crypto"
+)
+
+// MigrationResult describes what was copied during a vault migration
+type MigrationResult struct {
+	MachinesAdded   []string
+	MachinesSkipped []string // Already registered in the target vault
+	Environments    []string
+	Secrets         int
+	WrappedKeys     int
+	Verified        map[string]int // Environment -> number of secrets that decrypt
+	Unverified      []string       // Environments this machine has no access to
+}
+
+// MigrateVault copies machines, secrets and wrapped keys from source to target.
+// Both paths are produced by GetVaultPaths, so the layout differences between
+// local and global mode are handled by the paths themselves. Machine lists are
+// merged; environments that already exist in the target are rejected before
+// anything is copied. The source vault is left untouched.
+//
+// The copy is staged in a transaction and only committed once every
+// environment the current machine can access decrypts in the target layout,
+// so a failed migration leaves the target unchanged and can be retried.
+// Callers must hold the lock of the target vault.
+func MigrateVault(source, target *Paths) (*MigrationResult, error) {
+	environments, err := ListEnvironments(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list environments: %w", err)
+	}
+
+	for _, env := range environments {
+		if EnvironmentExists(target, env) {
+			return nil, fmt.Errorf("environment '%s' already exists in target vault", env)
+		}
+	}
+
+	result := &MigrationResult{Environments: environments, Verified: make(map[string]int)}
+
+	tx, err := beginTransaction(target.Root, target.Storage(), "migrate vault")
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	staged := target.WithTransaction(tx)
+
+	// Merge machine lists
+	machines, err := ListMachines(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list machines: %w", err)
+	}
+
+	for _, machine := range machines {
+		targetPath := target.GetMachineInfoPath(machine.ID)
+		if target.Storage().Exists(targetPath) {
+			existing, err := loadMachineInfo(target.Storage(), targetPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load machine %s from target vault: %w", machine.ID, err)
+			}
+			if existing.PublicKey != machine.PublicKey {
+				return nil, fmt.Errorf("machine %s exists in both vaults with different keys", machine.ID)
+			}
+			result.MachinesSkipped = append(result.MachinesSkipped, machine.ID)
+			continue
+		}
+
+		if err := AddMachineToVault(staged, machine); err != nil {
+			return nil, err
+		}
+		result.MachinesAdded = append(result.MachinesAdded, machine.ID)
+	}
+
+	// Copy secrets and wrapped keys per environment
+	for _, env := range environments {
+		secrets, err := copyDirFiles(source.Storage(), staged.Storage(), source.GetSecretsPath(env), target.GetSecretsPath(env))
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy secrets for environment '%s': %w", env, err)
+		}
+		result.Secrets += secrets
+
+		wrappedKeys, err := copyDirFiles(source.Storage(), staged.Storage(), source.GetWrappedKeysEnvPath(env), target.GetWrappedKeysEnvPath(env))
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy wrapped keys for environment '%s': %w", env, err)
+		}
+		result.WrappedKeys += wrappedKeys
+	}
+
+	// Verify every secret decrypts in the target layout before committing
+	migrated := target.WithStorage(tx.Staged())
+	for _, env := range environments {
+		count, err := VerifyEnvironmentDecrypts(migrated, env)
+		if errors.Is(err, ErrAccessDenied) {
+			result.Unverified = append(result.Unverified, env)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("verification failed for environment '%s': %w", env, err)
+		}
+		result.Verified[env] = count
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to apply migration: %w", err)
+	}
+
+	sort.Strings(result.MachinesAdded)
+	sort.Strings(result.MachinesSkipped)
+
+	return result, nil
+}
+
+// VerifyEnvironmentDecrypts checks that the current machine can decrypt every
+// secret of an environment. Returns the number of verified secrets.
+func VerifyEnvironmentDecrypts(paths *Paths, environment string) (int, error) {
+	masterKey, err := UnwrapMasterKey(paths, environment)
+	if err != nil {
+		return 0, err
+	}
+	defer crypto.ZeroBytes(masterKey)
+
+	secretFiles, err := paths.Storage().ListFiles(paths.GetSecretsPath(environment))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, secretFile := range secretFiles {
+		key := GetSecretKeyFromFilename(secretFile)
+
+		encrypted, err := LoadEncryptedSecret(paths, environment, key)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load secret %s: %w", key, err)
+		}
+
+		if _, err := DecryptSecret(masterKey, encrypted); err != nil {
+			return 0, fmt.Errorf("failed to decrypt secret %s: %w", key, err)
+		}
+	}
+
+	return len(secretFiles), nil
+}
+
+// copyDirFiles copies all files directly inside a directory to another,
+// possibly in a different storage
+func copyDirFiles(source, target Storage, sourceDir, targetDir string) (int, error) {
+	files, err := source.ListFiles(sourceDir)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, file := range files {
+		data, err := source.ReadFile(file)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := target.WriteFile(filepath.Join(targetDir, filepath.Base(file)), data, FilePerm); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(files), nil
+}
@@ -0,0 +1,152 @@
+package vault
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iluxav/nvolt/internal/crypto"
+)
+
+func TestMigrateLocalToGlobal(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("NVOLT_CONFIG", home)
+
+	machineInfo, err := InitializeMachine("dev")
+	if err != nil {
+		t.Fatalf("Failed to initialize machine: %v", err)
+	}
+
+	// Local source vault with the current machine
+	localPath := filepath.Join(t.TempDir(), NvoltDir)
+	if err := InitializeVaultDirectory(localPath); err != nil {
+		t.Fatalf("Failed to initialize vault: %v", err)
+	}
+	source := GetVaultPaths(localPath, "")
+	if err := AddMachineToVault(source, machineInfo); err != nil {
+		t.Fatalf("Failed to add machine: %v", err)
+	}
+	pushTestSecrets(t, source, "default", machineInfo.ID, map[string]string{"A": "1", "B": "2"})
+	pushTestSecrets(t, source, "production", machineInfo.ID, map[string]string{"C": "3"})
+
+	// Global target vault already knows the current machine
+	globalPath := filepath.Join(home, OrgsDir, "acme", "secrets")
+	target := GetVaultPaths(globalPath, "app")
+	if GetVaultMode(globalPath) != ModeGlobal {
+		t.Fatal("Expected target to be a global vault")
+	}
+	if err := AddMachineToVault(target, machineInfo); err != nil {
+		t.Fatalf("Failed to add machine: %v", err)
+	}
+
+	result, err := MigrateVault(source, target)
+	if err != nil {
+		t.Fatalf("MigrateVault failed: %v", err)
+	}
+
+	if result.Secrets != 3 || result.WrappedKeys != 2 {
+		t.Errorf("Expected 3 secrets and 2 wrapped keys, got %d and %d", result.Secrets, result.WrappedKeys)
+	}
+	if len(result.MachinesSkipped) != 1 || len(result.MachinesAdded) != 0 {
+		t.Errorf("Expected existing machine to be skipped, got added=%v skipped=%v", result.MachinesAdded, result.MachinesSkipped)
+	}
+
+	// Global layout: machines at root, secrets under the project
+	if !FileExists(filepath.Join(globalPath, MachinesDir, machineInfo.ID+".json")) {
+		t.Error("Machine should be at the repository root")
+	}
+	if !FileExists(filepath.Join(globalPath, "app", SecretsDir, "production", "C.enc.json")) {
+		t.Error("Secret should be under the project directory")
+	}
+
+	for _, env := range result.Environments {
+		if _, err := VerifyEnvironmentDecrypts(target, env); err != nil {
+			t.Errorf("Environment %s does not decrypt after migration: %v", env, err)
+		}
+	}
+
+	// Environments the machine was not granted cannot be verified
+	newTestMachine(t, target, "m-other")
+	pushTestSecrets(t, target, "staging", "m-other", map[string]string{"D": "4"})
+	if _, err := VerifyEnvironmentDecrypts(target, "staging"); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("Expected ErrAccessDenied, got %v", err)
+	}
+
+	// Migrating again conflicts with the existing environments
+	if _, err := MigrateVault(source, target); err == nil {
+		t.Error("Expected error when environments already exist in target")
+	}
+}
+
+func TestMigrateMachineConflict(t *testing.T) {
+	source, _ := newTestVault(t)
+	target, _ := newTestVault(t) // Same machine ID with a different key
+
+	if _, err := MigrateVault(source, target); err == nil {
+		t.Error("Expected error for conflicting machine keys")
+	}
+}
+
+func TestMigrateVerificationFailure(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("NVOLT_CONFIG", home)
+
+	machineInfo, err := InitializeMachine("dev")
+	if err != nil {
+		t.Fatalf("Failed to initialize machine: %v", err)
+	}
+
+	source, _ := newTestVault(t)
+	if err := AddMachineToVault(source, machineInfo); err != nil {
+		t.Fatalf("Failed to add machine: %v", err)
+	}
+	pushTestSecrets(t, source, "default", machineInfo.ID, map[string]string{"A": "1"})
+	pushTestSecrets(t, source, "production", machineInfo.ID, map[string]string{"B": "2"})
+
+	// A secret encrypted with another key does not decrypt
+	otherKey, err := crypto.GenerateAESKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	encrypted, err := EncryptSecret(otherKey, "corrupt")
+	if err != nil {
+		t.Fatalf("Failed to encrypt secret: %v", err)
+	}
+	if err := SaveEncryptedSecret(source, "production", "BAD", encrypted); err != nil {
+		t.Fatalf("Failed to save secret: %v", err)
+	}
+
+	targetPath := filepath.Join(t.TempDir(), NvoltDir)
+	if err := InitializeVaultDirectory(targetPath); err != nil {
+		t.Fatalf("Failed to initialize vault: %v", err)
+	}
+	target := GetVaultPaths(targetPath, "")
+
+	if _, err := MigrateVault(source, target); err == nil || !strings.Contains(err.Error(), "production") {
+		t.Fatalf("Expected verification of production to fail, got %v", err)
+	}
+
+	// Nothing was applied to the target
+	if envs, _ := ListEnvironments(target); len(envs) != 0 {
+		t.Errorf("Target should have no environments after a failed migration, got %v", envs)
+	}
+	if machines, _ := ListMachines(target); len(machines) != 0 {
+		t.Errorf("Target should have no machines after a failed migration, got %d", len(machines))
+	}
+
+	// Once the source is fixed, the migration can be retried
+	if err := DeleteFile(source.GetSecretFilePath("production", "BAD")); err != nil {
+		t.Fatalf("Failed to remove secret: %v", err)
+	}
+	result, err := MigrateVault(source, target)
+	if err != nil {
+		t.Fatalf("Retrying MigrateVault failed: %v", err)
+	}
+	if result.Verified["default"] != 1 || result.Verified["production"] != 1 {
+		t.Errorf("Expected both environments to be verified, got %v", result.Verified)
+	}
+	if len(result.Unverified) != 0 {
+		t.Errorf("Expected no unverified environments, got %v", result.Unverified)
+	}
+}
@@ -0,0 +1,94 @@
+package vault
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/iluxav/nvolt/internal/validation"
+)
+
+// validateSecretPath checks the names that make up the path of a secret
+func validateSecretPath(environment, key string) error {
+	if err := validation.ValidateEnvironmentName(environment); err != nil {
+		return err
+	}
+	return validation.ValidateSecretKey(key)
+}
+
+// validateWrappedKeyPath checks the names that make up the path of a wrapped key
+func validateWrappedKeyPath(environment, machineID string) error {
+	if err := validation.ValidateEnvironmentName(environment); err != nil {
+		return err
+	}
+	return validation.ValidateMachineID(machineID)
+}
+
+// FindInvalidNames returns a description of every project, environment,
+// secret key and machine ID stored in the vault outside the name grammar
+func FindInvalidNames(vaultPath string) ([]string, error) {
+	var problems []string
+	report := func(kind, name, location string, err error) {
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("Invalid %s '%s' in %s", kind, name, location))
+		}
+	}
+
+	projects, err := ListProjects(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Machines are shared by every project
+	machinePaths := GetVaultPaths(vaultPath, "")
+	machineFiles, err := machinePaths.Storage().ListFiles(machinePaths.Machines)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range machineFiles {
+		name := strings.TrimSuffix(GetDirName(file), ".json")
+		report("machine ID", name, MachinesDir, validation.ValidateMachineID(name))
+
+		if info, err := loadMachineInfo(machinePaths.Storage(), file); err == nil && info.ID != name {
+			report("machine ID", info.ID, file, validation.ValidateMachineID(info.ID))
+		}
+	}
+
+	for _, project := range projects {
+		location := "the vault"
+		if project != "" {
+			location = "project " + project
+			report("project", project, "the vault", validation.ValidateProjectName(project))
+		}
+
+		paths := GetVaultPaths(vaultPath, project)
+		environments, err := ListEnvironments(paths)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, env := range environments {
+			report("environment", env, location, validation.ValidateEnvironmentName(env))
+
+			secretFiles, err := paths.Storage().ListFiles(filepath.Join(paths.Secrets, env))
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range secretFiles {
+				key := GetSecretKeyFromFilename(file)
+				report("secret key", key, fmt.Sprintf("%s/%s", location, env), validation.ValidateSecretKey(key))
+			}
+
+			keyFiles, err := paths.Storage().ListFiles(filepath.Join(paths.WrappedKeys, env))
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range keyFiles {
+				id := strings.TrimSuffix(GetDirName(file), ".json")
+				report("wrapped key machine ID", id, fmt.Sprintf("%s/%s", location, env), validation.ValidateMachineID(id))
+			}
+		}
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,65 @@
+package vault
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretPathsRejectTraversal(t *testing.T) {
+	paths, _ := newTestVault(t)
+	masterKey := pushTestSecrets(t, paths, "dev", "m-test", map[string]string{"A": "1"})
+
+	encrypted, err := EncryptSecret(masterKey, "evil")
+	if err != nil {
+		t.Fatalf("EncryptSecret failed: %v", err)
+	}
+
+	if err := SaveEncryptedSecret(paths, "dev", "../../machines/evil", encrypted); err == nil {
+		t.Error("SaveEncryptedSecret should reject keys with path separators")
+	}
+	if err := SaveEncryptedSecret(paths, "../x", "A", encrypted); err == nil {
+		t.Error("SaveEncryptedSecret should reject environments with path separators")
+	}
+	if _, err := GrantMachineAccess(paths, "dev", "../m-test", masterKey, "m-test"); err == nil {
+		t.Error("GrantMachineAccess should reject machine IDs with path separators")
+	}
+	if err := DeleteEnvironment(paths, ".."); err == nil {
+		t.Error("DeleteEnvironment should reject '..'")
+	}
+
+	if _, err := ParseKeyValuePairs([]string{"../../evil=x"}); err == nil {
+		t.Error("ParseKeyValuePairs should reject invalid keys")
+	}
+
+	if FileExists(filepath.Join(paths.Machines, "evil.enc.json")) {
+		t.Error("Nothing should be written outside the environment directory")
+	}
+}
+
+func TestFindInvalidNames(t *testing.T) {
+	paths, _ := newTestVault(t)
+	pushTestSecrets(t, paths, "dev", "m-test", map[string]string{"A": "1"})
+
+	if problems, err := FindInvalidNames(paths.Root); err != nil || len(problems) != 0 {
+		t.Fatalf("FindInvalidNames = %v, %v; want none", problems, err)
+	}
+
+	// Names written by older versions, bypassing validation
+	if err := paths.writeFile(filepath.Join(paths.Secrets, "dev", "my key.enc.json"), []byte("{}"), FilePerm); err != nil {
+		t.Fatalf("writeFile failed: %v", err)
+	}
+	if err := paths.writeFile(filepath.Join(paths.Secrets, "prod.eu", "A.enc.json"), []byte("{}"), FilePerm); err != nil {
+		t.Fatalf("writeFile failed: %v", err)
+	}
+
+	problems, err := FindInvalidNames(paths.Root)
+	if err != nil {
+		t.Fatalf("FindInvalidNames failed: %v", err)
+	}
+
+	joined := strings.Join(problems, "\n")
+	if len(problems) != 2 || !strings.Contains(joined, "'my key'") || !strings.Contains(joined, "'prod.eu'") {
+		t.Errorf("FindInvalidNames = %v", problems)
+	}
+}
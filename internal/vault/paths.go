@@ -46,6 +46,9 @@ type Paths struct {
 
 	// Config file
 	Config string
+
+	// storage overrides the vault's storage when set (see WithStorage)
+	storage Storage
 }
 
 // HomePaths holds paths in the home directory
@@ -0,0 +1,215 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/iluxav/nvolt/pkg/types"
+)
+
+// SchemaVersion is the vault format version written by this version of nvolt.
+// Vaults without a config.json are treated as version 0.
+const SchemaVersion = 1
+
+// Migration upgrades a vault from Version-1 to Version.
+// Apply must be idempotent and must not write anything when dryRun is true.
+// It returns a description of each action performed (or that would be performed).
+type Migration struct {
+	Version     int
+	Description string
+	Apply       func(vaultPath string, cfg *types.VaultConfig, dryRun bool) ([]string, error)
+}
+
+// UpgradeStep reports the outcome of a single migration
+type UpgradeStep struct {
+	Version     int
+	Description string
+	Actions     []string
+}
+
+// migrations are applied in order; each one bumps the recorded schema version
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "Record vault mode and schema version in config.json",
+		Apply:       migrateRecordVaultConfig,
+	},
+}
+
+// GetVaultConfigPath returns the path of the vault-level config.json
+// (.nvolt/config.json in local mode, <repo>/config.json in global mode)
+func GetVaultConfigPath(vaultPath string) string {
+	return GetVaultPaths(vaultPath, "").Config
+}
+
+// NewVaultConfig creates a config for a vault at the current schema version
+func NewVaultConfig(vaultPath string) *types.VaultConfig {
+	cfg := &types.VaultConfig{SchemaVersion: SchemaVersion}
+	fillVaultConfig(vaultPath, cfg)
+	return cfg
+}
+
+// LoadVaultConfig loads the vault config. Returns (nil, nil) if the vault has no config.json.
+func LoadVaultConfig(vaultPath string) (*types.VaultConfig, error) {
+	storage, err := StorageFor(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := storage.ReadFile(GetVaultConfigPath(vaultPath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault config: %w", err)
+	}
+
+	var cfg types.VaultConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse vault config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// SaveVaultConfig writes the vault config
+func SaveVaultConfig(vaultPath string, cfg *types.VaultConfig) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault config: %w", err)
+	}
+
+	storage, err := StorageFor(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	if err := storage.WriteFile(GetVaultConfigPath(vaultPath), data, FilePerm); err != nil {
+		return fmt.Errorf("failed to write vault config: %w", err)
+	}
+
+	return nil
+}
+
+// GetSchemaVersion returns the schema version recorded in the vault (0 if none)
+func GetSchemaVersion(vaultPath string) (int, error) {
+	cfg, err := LoadVaultConfig(vaultPath)
+	if err != nil {
+		return 0, err
+	}
+	if cfg == nil {
+		return 0, nil
+	}
+	return cfg.SchemaVersion, nil
+}
+
+// CheckCompatibility verifies that this version of nvolt can use the vault.
+// Vaults with a newer schema can be read on a best-effort basis but are never
+// written to, since doing so could corrupt data this version doesn't understand.
+func CheckCompatibility(vaultPath string, write bool) error {
+	version, err := GetSchemaVersion(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	if write && version > SchemaVersion {
+		return fmt.Errorf("vault schema version %d is newer than the supported version %d: upgrade nvolt before modifying this vault", version, SchemaVersion)
+	}
+
+	return nil
+}
+
+// PendingMigrations returns the migrations that have not been applied to the vault
+func PendingMigrations(vaultPath string) ([]Migration, error) {
+	version, err := GetSchemaVersion(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// UpgradeVault applies all pending migrations in order, recording the schema
+// version after each one so an interrupted upgrade resumes where it stopped.
+// With dryRun, nothing is written and the planned actions are returned.
+func UpgradeVault(vaultPath string, dryRun bool) ([]UpgradeStep, error) {
+	if err := CheckCompatibility(vaultPath, true); err != nil {
+		return nil, err
+	}
+
+	cfg, err := LoadVaultConfig(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		cfg = &types.VaultConfig{}
+	}
+
+	pending, err := PendingMigrations(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var steps []UpgradeStep
+	for _, m := range pending {
+		actions, err := m.Apply(vaultPath, cfg, dryRun)
+		if err != nil {
+			return steps, fmt.Errorf("migration to version %d failed: %w", m.Version, err)
+		}
+
+		cfg.SchemaVersion = m.Version
+		if !dryRun {
+			if err := SaveVaultConfig(vaultPath, cfg); err != nil {
+				return steps, err
+			}
+		}
+
+		steps = append(steps, UpgradeStep{Version: m.Version, Description: m.Description, Actions: actions})
+	}
+
+	return steps, nil
+}
+
+// migrateRecordVaultConfig fills in the vault mode and repository
+func migrateRecordVaultConfig(vaultPath string, cfg *types.VaultConfig, dryRun bool) ([]string, error) {
+	before := *cfg
+	fillVaultConfig(vaultPath, cfg)
+
+	var actions []string
+	if before.Mode != cfg.Mode {
+		actions = append(actions, fmt.Sprintf("set mode to '%s'", cfg.Mode))
+	}
+	if before.Repository != cfg.Repository {
+		actions = append(actions, fmt.Sprintf("set repository to '%s'", cfg.Repository))
+	}
+	actions = append(actions, fmt.Sprintf("write %s", filepath.Base(GetVaultConfigPath(vaultPath))))
+
+	return actions, nil
+}
+
+// fillVaultConfig sets mode and repository fields derived from the vault path
+func fillVaultConfig(vaultPath string, cfg *types.VaultConfig) {
+	if cfg.Mode == "" {
+		cfg.Mode = "local"
+		if IsGlobalMode(vaultPath) {
+			cfg.Mode = "global"
+		}
+	}
+
+	if cfg.Repository == "" && IsGlobalMode(vaultPath) {
+		if homePaths, err := GetHomePaths(); err == nil {
+			if rel, err := filepath.Rel(homePaths.Orgs, vaultPath); err == nil {
+				cfg.Repository = filepath.ToSlash(rel)
+			}
+		}
+	}
+}
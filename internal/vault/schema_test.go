@@ -0,0 +1,91 @@
+package vault
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/iluxav/nvolt/pkg/types"
+)
+
+func TestUpgradeLegacyVault(t *testing.T) {
+	t.Setenv("NVOLT_CONFIG", t.TempDir())
+
+	vaultPath := filepath.Join(t.TempDir(), NvoltDir)
+	if err := InitializeVaultDirectory(vaultPath); err != nil {
+		t.Fatalf("Failed to initialize vault: %v", err)
+	}
+
+	version, err := GetSchemaVersion(vaultPath)
+	if err != nil {
+		t.Fatalf("GetSchemaVersion failed: %v", err)
+	}
+	if version != 0 {
+		t.Errorf("Expected legacy vault to be version 0, got %d", version)
+	}
+
+	// Dry run reports but doesn't write
+	steps, err := UpgradeVault(vaultPath, true)
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if len(steps) != len(migrations) {
+		t.Errorf("Expected %d planned steps, got %d", len(migrations), len(steps))
+	}
+	if FileExists(GetVaultConfigPath(vaultPath)) {
+		t.Error("Dry run should not write config.json")
+	}
+
+	// Real upgrade
+	if _, err := UpgradeVault(vaultPath, false); err != nil {
+		t.Fatalf("Upgrade failed: %v", err)
+	}
+
+	cfg, err := LoadVaultConfig(vaultPath)
+	if err != nil || cfg == nil {
+		t.Fatalf("Expected config.json after upgrade: %v", err)
+	}
+	if cfg.SchemaVersion != SchemaVersion || cfg.Mode != "local" {
+		t.Errorf("Unexpected config after upgrade: %+v", cfg)
+	}
+
+	// Upgrading again is a no-op
+	steps, err = UpgradeVault(vaultPath, false)
+	if err != nil {
+		t.Fatalf("Second upgrade failed: %v", err)
+	}
+	if len(steps) != 0 {
+		t.Errorf("Expected no pending migrations, got %d", len(steps))
+	}
+}
+
+func TestCheckCompatibilityNewerVault(t *testing.T) {
+	vaultPath := filepath.Join(t.TempDir(), NvoltDir)
+	if err := SaveVaultConfig(vaultPath, &types.VaultConfig{SchemaVersion: SchemaVersion + 1}); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	if err := CheckCompatibility(vaultPath, false); err != nil {
+		t.Errorf("Reading a newer vault should be allowed: %v", err)
+	}
+	if err := CheckCompatibility(vaultPath, true); err == nil {
+		t.Error("Writing to a newer vault should be refused")
+	}
+	if _, err := UpgradeVault(vaultPath, false); err == nil {
+		t.Error("Upgrading a newer vault should be refused")
+	}
+}
+
+func TestGlobalVaultConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("NVOLT_CONFIG", home)
+
+	vaultPath := filepath.Join(home, OrgsDir, "acme", "secrets")
+	cfg := NewVaultConfig(vaultPath)
+
+	if cfg.Mode != "global" || cfg.Repository != "acme/secrets" {
+		t.Errorf("Unexpected global config: %+v", cfg)
+	}
+	if GetVaultConfigPath(vaultPath) != filepath.Join(vaultPath, ConfigFile) {
+		t.Errorf("Global config should live at the repository root, got %s", GetVaultConfigPath(vaultPath))
+	}
+}
@@ -1,62 +1,40 @@
 package vault
 
 import (
-	"bufio"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/iluxav/nvolt/internal/crypto"
+	"github.com/iluxav/nvolt/internal/dotenv"
+	"github.com/iluxav/nvolt/internal/validation"
 	"github.com/iluxav/nvolt/pkg/types"
 )
 
+// ErrMachineNotFound is returned when granting access to a machine that is not
+// registered in the vault
+var ErrMachineNotFound = errors.New("machine not found in vault")
+
+// ErrAccessDenied is returned when the current machine has no wrapped key for
+// an environment
+var ErrAccessDenied = errors.New("access denied")
+
 // ParseEnvFile parses a .env file into a map of key-value pairs
 func ParseEnvFile(path string) (map[string]string, error) {
-	file, err := os.Open(path)
+	entries, err := dotenv.ParseFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
-
-	envVars := make(map[string]string)
-	scanner := bufio.NewScanner(file)
-	lineNum := 0
-
-	for scanner.Scan() {
-		lineNum++
-		line := strings.TrimSpace(scanner.Text())
 
-		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
+	for _, entry := range entries {
+		if err := validation.ValidateSecretKey(entry.Key); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, entry.Line, err)
 		}
-
-		// Parse KEY=VALUE
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid format at line %d: %s", lineNum, line)
-		}
-
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		// Remove quotes if present
-		value = strings.Trim(value, "\"'")
-
-		if key == "" {
-			return nil, fmt.Errorf("empty key at line %d", lineNum)
-		}
-
-		envVars[key] = value
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %w", err)
 	}
 
-	return envVars, nil
+	return dotenv.ToMap(entries), nil
 }
 
 // ParseKeyValuePairs parses command-line KEY=VALUE pairs
@@ -75,6 +53,9 @@ func ParseKeyValuePairs(pairs []string) (map[string]string, error) {
 		if key == "" {
 			return nil, fmt.Errorf("empty key in: %s", pair)
 		}
+		if err := validation.ValidateSecretKey(key); err != nil {
+			return nil, err
+		}
 
 		envVars[key] = value
 	}
@@ -125,6 +106,10 @@ func DecryptSecret(masterKey []byte, encrypted *types.EncryptedSecret) (string,
 
 // SaveEncryptedSecret saves an encrypted secret to a file
 func SaveEncryptedSecret(paths *Paths, environment, key string, encrypted *types.EncryptedSecret) error {
+	if err := validateSecretPath(environment, key); err != nil {
+		return err
+	}
+
 	// Ensure secrets directory exists
 	if err := EnsureSecretsDir(paths, environment); err != nil {
 		return err
@@ -137,7 +122,7 @@ func SaveEncryptedSecret(paths *Paths, environment, key string, encrypted *types
 		return fmt.Errorf("failed to marshal secret: %w", err)
 	}
 
-	if err := WriteFileAtomic(secretPath, data, FilePerm); err != nil {
+	if err := paths.writeFile(secretPath, data, FilePerm); err != nil {
 		return fmt.Errorf("failed to write secret: %w", err)
 	}
 
@@ -146,9 +131,13 @@ func SaveEncryptedSecret(paths *Paths, environment, key string, encrypted *types
 
 // LoadEncryptedSecret loads an encrypted secret from a file
 func LoadEncryptedSecret(paths *Paths, environment, key string) (*types.EncryptedSecret, error) {
+	if err := validateSecretPath(environment, key); err != nil {
+		return nil, err
+	}
+
 	secretPath := paths.GetSecretFilePath(environment, key)
 
-	data, err := ReadFile(secretPath)
+	data, err := paths.Storage().ReadFile(secretPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read secret: %w", err)
 	}
@@ -165,6 +154,10 @@ func LoadEncryptedSecret(paths *Paths, environment, key string) (*types.Encrypte
 // Uses unified paths - works identically in both local and global modes
 // If autoGrant is true, automatically grants access to all machines without prompting
 func WrapMasterKeyForMachines(paths *Paths, environment string, masterKey []byte, grantedBy string, autoGrant bool) error {
+	if err := validation.ValidateEnvironmentName(environment); err != nil {
+		return err
+	}
+
 	// Get all machines
 	machines, err := ListMachines(paths)
 	if err != nil {
@@ -177,7 +170,7 @@ func WrapMasterKeyForMachines(paths *Paths, environment string, masterKey []byte
 
 	// Ensure wrapped keys environment directory exists
 	envDir := paths.GetWrappedKeysEnvPath(environment)
-	if err := ensureDir(envDir, DirPerm); err != nil {
+	if err := paths.ensureDir(envDir); err != nil {
 		return fmt.Errorf("failed to create wrapped keys directory: %w", err)
 	}
 
@@ -186,7 +179,7 @@ func WrapMasterKeyForMachines(paths *Paths, environment string, masterKey []byte
 		wrappedKeyPath := paths.GetWrappedKeyPath(environment, machine.ID)
 
 		// Check if wrapped key already exists
-		keyExists := FileExists(wrappedKeyPath)
+		keyExists := paths.Storage().Exists(wrappedKeyPath)
 
 		// If key doesn't exist and not auto-granting, prompt for permission
 		// Skip prompt for the current machine (self)
@@ -233,7 +226,7 @@ func WrapMasterKeyForMachines(paths *Paths, environment string, masterKey []byte
 			return fmt.Errorf("failed to marshal wrapped key: %w", err)
 		}
 
-		if err := WriteFileAtomic(wrappedKeyPath, data, FilePerm); err != nil {
+		if err := paths.writeFile(wrappedKeyPath, data, FilePerm); err != nil {
 			return fmt.Errorf("failed to save wrapped key for %s: %w", machine.ID, err)
 		}
 	}
@@ -244,6 +237,10 @@ func WrapMasterKeyForMachines(paths *Paths, environment string, masterKey []byte
 // WrapMasterKeyForExistingMachines wraps the master key ONLY for machines that already have access
 // This is used during push to update existing keys without granting new access
 func WrapMasterKeyForExistingMachines(paths *Paths, environment string, masterKey []byte, grantedBy string) error {
+	if err := validation.ValidateEnvironmentName(environment); err != nil {
+		return err
+	}
+
 	// Get all machines
 	machines, err := ListMachines(paths)
 	if err != nil {
@@ -256,7 +253,7 @@ func WrapMasterKeyForExistingMachines(paths *Paths, environment string, masterKe
 
 	// Ensure wrapped keys environment directory exists
 	envDir := paths.GetWrappedKeysEnvPath(environment)
-	if err := ensureDir(envDir, DirPerm); err != nil {
+	if err := paths.ensureDir(envDir); err != nil {
 		return fmt.Errorf("failed to create wrapped keys directory: %w", err)
 	}
 
@@ -265,7 +262,7 @@ func WrapMasterKeyForExistingMachines(paths *Paths, environment string, masterKe
 		wrappedKeyPath := paths.GetWrappedKeyPath(environment, machine.ID)
 
 		// Only wrap if key already exists or if it's the current machine
-		if !FileExists(wrappedKeyPath) && machine.ID != grantedBy {
+		if !paths.Storage().Exists(wrappedKeyPath) && machine.ID != grantedBy {
 			continue // Skip machines without existing access
 		}
 
@@ -296,7 +293,7 @@ func WrapMasterKeyForExistingMachines(paths *Paths, environment string, masterKe
 			return fmt.Errorf("failed to marshal wrapped key: %w", err)
 		}
 
-		if err := WriteFileAtomic(wrappedKeyPath, data, FilePerm); err != nil {
+		if err := paths.writeFile(wrappedKeyPath, data, FilePerm); err != nil {
 			return fmt.Errorf("failed to save wrapped key for %s: %w", machine.ID, err)
 		}
 	}
@@ -308,6 +305,10 @@ func WrapMasterKeyForExistingMachines(paths *Paths, environment string, masterKe
 // Returns (wasGranted, error) where wasGranted indicates if access was newly granted
 // Returns (false, nil) if machine already has access (not an error)
 func GrantMachineAccess(paths *Paths, environment, machineID string, masterKey []byte, grantedBy string) (bool, error) {
+	if err := validateWrappedKeyPath(environment, machineID); err != nil {
+		return false, err
+	}
+
 	// Get all machines
 	machines, err := ListMachines(paths)
 	if err != nil {
@@ -324,18 +325,18 @@ func GrantMachineAccess(paths *Paths, environment, machineID string, masterKey [
 	}
 
 	if targetMachine == nil {
-		return false, fmt.Errorf("machine '%s' not found in vault", machineID)
+		return false, fmt.Errorf("%w: '%s'", ErrMachineNotFound, machineID)
 	}
 
 	// Check if machine already has access
 	wrappedKeyPath := paths.GetWrappedKeyPath(environment, machineID)
-	if FileExists(wrappedKeyPath) {
+	if paths.Storage().Exists(wrappedKeyPath) {
 		return false, nil // Already has access - not an error
 	}
 
 	// Ensure wrapped keys environment directory exists
 	envDir := paths.GetWrappedKeysEnvPath(environment)
-	if err := ensureDir(envDir, DirPerm); err != nil {
+	if err := paths.ensureDir(envDir); err != nil {
 		return false, fmt.Errorf("failed to create wrapped keys directory: %w", err)
 	}
 
@@ -366,7 +367,7 @@ func GrantMachineAccess(paths *Paths, environment, machineID string, masterKey [
 		return false, fmt.Errorf("failed to marshal wrapped key: %w", err)
 	}
 
-	if err := WriteFileAtomic(wrappedKeyPath, data, FilePerm); err != nil {
+	if err := paths.writeFile(wrappedKeyPath, data, FilePerm); err != nil {
 		return false, fmt.Errorf("failed to save wrapped key for %s: %w", machineID, err)
 	}
 
@@ -376,6 +377,9 @@ func GrantMachineAccess(paths *Paths, environment, machineID string, masterKey [
 // UnwrapMasterKey unwraps the master key for the current machine in a specific environment
 // Uses unified paths - works identically in both local and global modes
 func UnwrapMasterKey(paths *Paths, environment string) ([]byte, error) {
+	if err := validation.ValidateEnvironmentName(environment); err != nil {
+		return nil, err
+	}
 
 	// Get current machine ID
 	machineID, err := GetCurrentMachineID()
@@ -385,9 +389,9 @@ func UnwrapMasterKey(paths *Paths, environment string) ([]byte, error) {
 
 	// Load wrapped key
 	wrappedKeyPath := paths.GetWrappedKeyPath(environment, machineID)
-	data, err := ReadFile(wrappedKeyPath)
+	data, err := paths.Storage().ReadFile(wrappedKeyPath)
 	if err != nil {
-		return nil, fmt.Errorf("access denied to '%s' environment: %w\nYou may need to request access from someone with push permissions", environment, err)
+		return nil, fmt.Errorf("%w to '%s' environment: %w\nYou may need to request access from someone with push permissions", ErrAccessDenied, environment, err)
 	}
 
 	var wrappedKeyData types.WrappedKey
@@ -416,17 +420,7 @@ func UnwrapMasterKey(paths *Paths, environment string) ([]byte, error) {
 	return masterKey, nil
 }
 
-// FormatEnvOutput formats secrets as .env format
+// FormatEnvOutput formats secrets as .env format with sorted keys
 func FormatEnvOutput(secrets map[string]string) string {
-	var builder strings.Builder
-
-	for key, value := range secrets {
-		// Quote values that contain spaces or special characters
-		if strings.ContainsAny(value, " \t\n\"'\\") {
-			value = fmt.Sprintf("\"%s\"", strings.ReplaceAll(value, "\"", "\\\""))
-		}
-		builder.WriteString(fmt.Sprintf("%s=%s\n", key, value))
-	}
-
-	return builder.String()
+	return dotenv.Format(secrets)
 }
@@ -0,0 +1,154 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DirectoryVaultsFile maps directories to their default global vault
+const DirectoryVaultsFile = "directories.json"
+
+// GlobalVault is a global vault cloned under ~/.nvolt/orgs
+type GlobalVault struct {
+	Org  string
+	Repo string
+	Path string
+}
+
+// Spec returns the org/repo name of the vault
+func (v GlobalVault) Spec() string {
+	return v.Org + "/" + v.Repo
+}
+
+// ListGlobalVaults returns the cloned global vaults, sorted by org/repo.
+// A repo is a vault if it has a machines/ directory or a vault config.
+func ListGlobalVaults() ([]GlobalVault, error) {
+	homePaths, err := GetHomePaths()
+	if err != nil {
+		return nil, err
+	}
+
+	orgDirs, err := ListDirs(homePaths.Orgs)
+	if err != nil {
+		return nil, err
+	}
+
+	var vaults []GlobalVault
+	for _, orgDir := range orgDirs {
+		repoDirs, err := ListDirs(orgDir)
+		if err != nil {
+			continue
+		}
+
+		for _, repoDir := range repoDirs {
+			if !FileExists(filepath.Join(repoDir, MachinesDir)) && !FileExists(GetVaultConfigPath(repoDir)) {
+				continue
+			}
+			vaults = append(vaults, GlobalVault{Org: GetDirName(orgDir), Repo: GetDirName(repoDir), Path: repoDir})
+		}
+	}
+
+	sort.Slice(vaults, func(i, j int) bool {
+		return vaults[i].Spec() < vaults[j].Spec()
+	})
+
+	return vaults, nil
+}
+
+// GetDirectoryVault returns the default vault (org/repo) of a directory and the
+// directory it was set on, which may be a parent. Returns "" if none is set.
+func GetDirectoryVault(dir string) (string, string, error) {
+	defaults, err := loadDirectoryVaults()
+	if err != nil {
+		return "", "", err
+	}
+
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	for {
+		if spec, ok := defaults[dir]; ok {
+			return spec, dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", nil
+		}
+		dir = parent
+	}
+}
+
+// SetDirectoryVault sets the default vault of a directory and its subdirectories.
+// An empty spec removes the default.
+func SetDirectoryVault(dir, spec string) error {
+	defaults, err := loadDirectoryVaults()
+	if err != nil {
+		return err
+	}
+
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+
+	if spec == "" {
+		delete(defaults, dir)
+	} else {
+		defaults[dir] = spec
+	}
+
+	path, err := getDirectoryVaultsPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(defaults, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal directory defaults: %w", err)
+	}
+
+	if err := WriteFileAtomic(path, data, FilePerm); err != nil {
+		return fmt.Errorf("failed to write directory defaults: %w", err)
+	}
+
+	return nil
+}
+
+// loadDirectoryVaults reads the directory defaults; a missing file is empty
+func loadDirectoryVaults() (map[string]string, error) {
+	path, err := getDirectoryVaultsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	defaults := make(map[string]string)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return defaults, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory defaults: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &defaults); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return defaults, nil
+}
+
+func getDirectoryVaultsPath() (string, error) {
+	homePaths, err := GetHomePaths()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homePaths.Root, DirectoryVaultsFile), nil
+}
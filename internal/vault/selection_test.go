@@ -0,0 +1,72 @@
+package vault
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListGlobalVaults(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("NVOLT_CONFIG", home)
+
+	orgs := filepath.Join(home, OrgsDir)
+	for _, dir := range []string{
+		filepath.Join(orgs, "zeta", "secrets", MachinesDir),
+		filepath.Join(orgs, "acme", "infra", MachinesDir),
+		filepath.Join(orgs, "acme", "notavault", "docs"),
+	} {
+		if err := os.MkdirAll(dir, DirPerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	vaults, err := ListGlobalVaults()
+	if err != nil {
+		t.Fatalf("ListGlobalVaults failed: %v", err)
+	}
+
+	var specs []string
+	for _, v := range vaults {
+		specs = append(specs, v.Spec())
+	}
+	if len(specs) != 2 || specs[0] != "acme/infra" || specs[1] != "zeta/secrets" {
+		t.Errorf("ListGlobalVaults = %v, want [acme/infra zeta/secrets]", specs)
+	}
+}
+
+func TestDirectoryVault(t *testing.T) {
+	t.Setenv("NVOLT_CONFIG", t.TempDir())
+
+	project := t.TempDir()
+	sub := filepath.Join(project, "services", "api")
+
+	if spec, _, err := GetDirectoryVault(sub); err != nil || spec != "" {
+		t.Fatalf("GetDirectoryVault with no defaults = %q, %v; want empty", spec, err)
+	}
+
+	if err := SetDirectoryVault(project, "acme/app"); err != nil {
+		t.Fatalf("SetDirectoryVault failed: %v", err)
+	}
+
+	// Subdirectories inherit the default
+	spec, dir, err := GetDirectoryVault(sub)
+	if err != nil || spec != "acme/app" || dir != project {
+		t.Errorf("GetDirectoryVault = %q, %q, %v; want acme/app set on %s", spec, dir, err, project)
+	}
+
+	// The closest directory wins
+	if err := SetDirectoryVault(sub, "acme/infra"); err != nil {
+		t.Fatalf("SetDirectoryVault failed: %v", err)
+	}
+	if spec, _, _ := GetDirectoryVault(sub); spec != "acme/infra" {
+		t.Errorf("GetDirectoryVault = %q, want acme/infra", spec)
+	}
+
+	if err := SetDirectoryVault(sub, ""); err != nil {
+		t.Fatalf("SetDirectoryVault unset failed: %v", err)
+	}
+	if spec, _, _ := GetDirectoryVault(sub); spec != "acme/app" {
+		t.Errorf("GetDirectoryVault after unset = %q, want acme/app", spec)
+	}
+}
@@ -0,0 +1,225 @@
+package vault
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Storage is where vault files live. Paths are the same vault paths used with
+// the filesystem (see GetVaultPaths); other backends treat them as keys and
+// directories as implicit prefixes.
+type Storage interface {
+	// ReadFile returns the contents of a file
+	ReadFile(path string) ([]byte, error)
+
+	// WriteFile atomically replaces a file, creating parent directories
+	WriteFile(path string, data []byte, perm fs.FileMode) error
+
+	// Remove removes a file or a directory tree. Missing paths are not an error.
+	Remove(path string) error
+
+	// ListFiles returns the sorted paths of the files directly inside dir.
+	// A missing directory is empty.
+	ListFiles(dir string) ([]string, error)
+
+	// ListDirs returns the sorted paths of the directories directly inside dir.
+	// A missing directory is empty.
+	ListDirs(dir string) ([]string, error)
+
+	// Exists reports whether a file or directory exists
+	Exists(path string) bool
+
+	// Batch runs fn and applies all of its writes and removals together,
+	// or none of them if fn or the batch fails. Reads inside fn see the
+	// state before the batch.
+	Batch(fn func(Storage) error) error
+}
+
+// SQLiteFile is the vault database; if it exists in a vault directory, the
+// vault is stored in it instead of in the directory tree
+const SQLiteFile = "vault.db"
+
+// Storage backend names
+const (
+	StorageFS     = "fs"
+	StorageSQLite = "sqlite"
+	StorageMemory = "memory"
+)
+
+var (
+	storagesMu sync.Mutex
+	storages   = make(map[string]Storage)
+)
+
+// StorageFor returns the storage of a vault: the SQLite database if the vault
+// has one, and the filesystem otherwise. Storages are opened once per process.
+func StorageFor(vaultPath string) (Storage, error) {
+	vaultPath = filepath.Clean(vaultPath)
+
+	storagesMu.Lock()
+	defer storagesMu.Unlock()
+
+	if s, ok := storages[vaultPath]; ok {
+		return s, nil
+	}
+
+	dbPath := filepath.Join(vaultPath, SQLiteFile)
+	if !FileExists(dbPath) {
+		// Not cached: the vault may be converted to SQLite later in this process
+		return NewFSStorage(vaultPath), nil
+	}
+
+	s, err := OpenSQLiteStorage(dbPath, vaultPath)
+	if err != nil {
+		return nil, err
+	}
+	storages[vaultPath] = s
+
+	return s, nil
+}
+
+// RegisterStorage makes StorageFor return s for a vault
+func RegisterStorage(vaultPath string, s Storage) {
+	storagesMu.Lock()
+	defer storagesMu.Unlock()
+	storages[filepath.Clean(vaultPath)] = s
+}
+
+// storageFor is StorageFor for callers that cannot return an error;
+// the failure is reported by every operation instead
+func storageFor(vaultPath string) Storage {
+	s, err := StorageFor(vaultPath)
+	if err != nil {
+		return &failedStorage{err: err}
+	}
+	return s
+}
+
+// StorageKind returns the backend name of a storage
+func StorageKind(s Storage) string {
+	switch s := s.(type) {
+	case *SQLiteStorage:
+		return StorageSQLite
+	case *MemoryStorage:
+		return StorageMemory
+	case *Transaction:
+		return StorageKind(s.base)
+	case *batchStorage:
+		return StorageKind(s.base)
+	default:
+		return StorageFS
+	}
+}
+
+// FSStorage stores the vault as a directory tree
+type FSStorage struct {
+	root string
+}
+
+// NewFSStorage returns the filesystem storage of a vault.
+// Batches are staged as transactions under the vault's state directory.
+func NewFSStorage(vaultPath string) *FSStorage {
+	return &FSStorage{root: vaultPath}
+}
+
+func (s *FSStorage) ReadFile(path string) ([]byte, error) {
+	return ReadFile(path)
+}
+
+func (s *FSStorage) WriteFile(path string, data []byte, perm fs.FileMode) error {
+	return WriteFileAtomic(path, data, perm)
+}
+
+func (s *FSStorage) Remove(path string) error {
+	return os.RemoveAll(path)
+}
+
+func (s *FSStorage) ListFiles(dir string) ([]string, error) {
+	files, err := ListFiles(dir)
+	sort.Strings(files)
+	return files, err
+}
+
+func (s *FSStorage) ListDirs(dir string) ([]string, error) {
+	dirs, err := ListDirs(dir)
+	sort.Strings(dirs)
+	return dirs, err
+}
+
+func (s *FSStorage) Exists(path string) bool {
+	return FileExists(path)
+}
+
+func (s *FSStorage) Batch(fn func(Storage) error) error {
+	tx, err := beginTransaction(s.root, s, "batch")
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// failedStorage reports a storage that could not be opened
+type failedStorage struct {
+	err error
+}
+
+func (s *failedStorage) ReadFile(string) ([]byte, error)             { return nil, s.err }
+func (s *failedStorage) WriteFile(string, []byte, fs.FileMode) error { return s.err }
+func (s *failedStorage) Remove(string) error                         { return s.err }
+func (s *failedStorage) ListFiles(string) ([]string, error)          { return nil, s.err }
+func (s *failedStorage) ListDirs(string) ([]string, error)           { return nil, s.err }
+func (s *failedStorage) Exists(string) bool                          { return false }
+func (s *failedStorage) Batch(func(Storage) error) error             { return s.err }
+
+// relativeKey converts a vault path to a slash-separated key relative to root
+func relativeKey(root, path string) (string, error) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside the vault", path)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// WithStorage returns a copy of the paths that reads and writes through s
+func (p *Paths) WithStorage(s Storage) *Paths {
+	copied := *p
+	copied.storage = s
+	return &copied
+}
+
+// Storage returns the storage the vault files are read from and written to
+func (p *Paths) Storage() Storage {
+	if p.storage != nil {
+		return p.storage
+	}
+	return storageFor(p.Root)
+}
+
+// writeFile writes a vault file through the storage
+func (p *Paths) writeFile(path string, data []byte, perm fs.FileMode) error {
+	return p.Storage().WriteFile(path, data, perm)
+}
+
+// remove removes a vault file or directory through the storage
+func (p *Paths) remove(path string) error {
+	return p.Storage().Remove(path)
+}
+
+// ensureDir creates a vault directory on the filesystem. Other storages and
+// transactions have implicit directories, so nothing is created for them.
+func (p *Paths) ensureDir(path string) error {
+	if _, ok := p.Storage().(*FSStorage); !ok {
+		return nil
+	}
+	return ensureDir(path, DirPerm)
+}
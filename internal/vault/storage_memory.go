@@ -0,0 +1,210 @@
+package vault
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemoryStorage keeps a vault in memory. It is meant for tests.
+type MemoryStorage struct {
+	root  string
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// NewMemoryStorage returns an empty in-memory storage for a vault path
+func NewMemoryStorage(vaultPath string) *MemoryStorage {
+	return &MemoryStorage{root: filepath.Clean(vaultPath), files: make(map[string][]byte)}
+}
+
+func (s *MemoryStorage) ReadFile(path string) ([]byte, error) {
+	key, err := relativeKey(s.root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, ok := s.files[key]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: path, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (s *MemoryStorage) WriteFile(path string, data []byte, perm fs.FileMode) error {
+	key, err := relativeKey(s.root, path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *MemoryStorage) Remove(path string) error {
+	key, err := relativeKey(s.root, path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(key)
+	return nil
+}
+
+// remove deletes a key and everything below it; the caller holds the lock
+func (s *MemoryStorage) remove(key string) {
+	for k := range s.files {
+		if keyWithin(k, key) {
+			delete(s.files, k)
+		}
+	}
+}
+
+func (s *MemoryStorage) ListFiles(dir string) ([]string, error) {
+	files, _, err := s.list(dir)
+	return files, err
+}
+
+func (s *MemoryStorage) ListDirs(dir string) ([]string, error) {
+	_, dirs, err := s.list(dir)
+	return dirs, err
+}
+
+func (s *MemoryStorage) list(dir string) ([]string, []string, error) {
+	key, err := relativeKey(s.root, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.mu.RLock()
+	keys := make([]string, 0, len(s.files))
+	for k := range s.files {
+		keys = append(keys, k)
+	}
+	s.mu.RUnlock()
+
+	files, dirs := listChildren(s.root, key, keys)
+	return files, dirs, nil
+}
+
+func (s *MemoryStorage) Exists(path string) bool {
+	key, err := relativeKey(s.root, path)
+	if err != nil {
+		return false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for k := range s.files {
+		if keyWithin(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStorage) Batch(fn func(Storage) error) error {
+	batch := &batchStorage{base: s}
+	if err := fn(batch); err != nil {
+		return err
+	}
+
+	keys := make([]string, len(batch.ops))
+	for i, op := range batch.ops {
+		key, err := relativeKey(s.root, op.path)
+		if err != nil {
+			return err
+		}
+		keys[i] = key
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, op := range batch.ops {
+		if op.remove {
+			s.remove(keys[i])
+		} else {
+			s.files[keys[i]] = op.data
+		}
+	}
+	return nil
+}
+
+// batchStorage records the writes and removals of a batch; reads go to base
+type batchStorage struct {
+	base Storage
+	ops  []batchOp
+}
+
+type batchOp struct {
+	path   string
+	data   []byte
+	perm   fs.FileMode
+	remove bool
+}
+
+func (b *batchStorage) ReadFile(path string) ([]byte, error)   { return b.base.ReadFile(path) }
+func (b *batchStorage) ListFiles(dir string) ([]string, error) { return b.base.ListFiles(dir) }
+func (b *batchStorage) ListDirs(dir string) ([]string, error)  { return b.base.ListDirs(dir) }
+func (b *batchStorage) Exists(path string) bool                { return b.base.Exists(path) }
+func (b *batchStorage) Batch(fn func(Storage) error) error     { return fn(b) }
+
+func (b *batchStorage) WriteFile(path string, data []byte, perm fs.FileMode) error {
+	b.ops = append(b.ops, batchOp{path: path, data: append([]byte(nil), data...), perm: perm})
+	return nil
+}
+
+func (b *batchStorage) Remove(path string) error {
+	b.ops = append(b.ops, batchOp{path: path, remove: true})
+	return nil
+}
+
+// keyWithin reports whether key is dir or below it ("." is the root)
+func keyWithin(key, dir string) bool {
+	return dir == "." || key == dir || strings.HasPrefix(key, dir+"/")
+}
+
+// listChildren returns the sorted files and directories directly inside dir,
+// as vault paths, given every key in a key-value storage
+func listChildren(root, dir string, keys []string) ([]string, []string) {
+	prefix := dir + "/"
+	if dir == "." {
+		prefix = ""
+	}
+
+	var files []string
+	seenDirs := make(map[string]bool)
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) || key == dir {
+			continue
+		}
+		rest := key[len(prefix):]
+		if i := strings.Index(rest, "/"); i >= 0 {
+			seenDirs[rest[:i]] = true
+		} else {
+			files = append(files, filepath.Join(root, filepath.FromSlash(key)))
+		}
+	}
+
+	dirs := make([]string, 0, len(seenDirs))
+	for name := range seenDirs {
+		dirs = append(dirs, filepath.Join(root, filepath.FromSlash(prefix+name)))
+	}
+
+	sort.Strings(files)
+	sort.Strings(dirs)
+	return files, dirs
+}
@@ -0,0 +1,230 @@
+package vault
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteSchema = `CREATE TABLE IF NOT EXISTS files (
+	path TEXT PRIMARY KEY,
+	data BLOB NOT NULL,
+	mode INTEGER NOT NULL
+)`
+
+// SQLiteStorage stores the vault in a single SQLite database file
+type SQLiteStorage struct {
+	db   *sql.DB
+	root string
+}
+
+// sqlExecer is implemented by both *sql.DB and *sql.Tx
+type sqlExecer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// OpenSQLiteStorage opens (or creates) the database of a vault
+func OpenSQLiteStorage(dbPath, vaultPath string) (*SQLiteStorage, error) {
+	if err := ensureDir(filepath.Dir(dbPath), DirPerm); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vault database: %w", err)
+	}
+	// A single connection keeps batches and reads consistent within the process
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize vault database %s: %w", dbPath, err)
+	}
+
+	if err := os.Chmod(dbPath, FilePerm); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to set vault database permissions: %w", err)
+	}
+
+	return &SQLiteStorage{db: db, root: filepath.Clean(vaultPath)}, nil
+}
+
+// InitializeSQLiteVault creates a vault stored in a SQLite database
+func InitializeSQLiteVault(vaultPath string) error {
+	s, err := OpenSQLiteStorage(filepath.Join(vaultPath, SQLiteFile), vaultPath)
+	if err != nil {
+		return err
+	}
+	RegisterStorage(vaultPath, s)
+
+	return nil
+}
+
+// Close closes the database
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteStorage) ReadFile(path string) ([]byte, error) {
+	key, err := relativeKey(s.root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	err = s.db.QueryRow("SELECT data FROM files WHERE path = ?", key).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &fs.PathError{Op: "read", Path: path, Err: fs.ErrNotExist}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from vault database: %w", path, err)
+	}
+
+	return data, nil
+}
+
+func (s *SQLiteStorage) WriteFile(path string, data []byte, perm fs.FileMode) error {
+	return s.write(s.db, path, data, perm)
+}
+
+func (s *SQLiteStorage) write(db sqlExecer, path string, data []byte, perm fs.FileMode) error {
+	key, err := relativeKey(s.root, path)
+	if err != nil {
+		return err
+	}
+
+	if data == nil {
+		data = []byte{}
+	}
+
+	_, err = db.Exec("INSERT INTO files (path, data, mode) VALUES (?, ?, ?) "+
+		"ON CONFLICT(path) DO UPDATE SET data = excluded.data, mode = excluded.mode",
+		key, data, int64(perm))
+	if err != nil {
+		return fmt.Errorf("failed to write %s to vault database: %w", path, err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStorage) Remove(path string) error {
+	return s.remove(s.db, path)
+}
+
+func (s *SQLiteStorage) remove(db sqlExecer, path string) error {
+	key, err := relativeKey(s.root, path)
+	if err != nil {
+		return err
+	}
+
+	if key == "." {
+		_, err = db.Exec("DELETE FROM files")
+	} else {
+		prefix := key + "/"
+		_, err = db.Exec("DELETE FROM files WHERE path = ? OR substr(path, 1, ?) = ?",
+			key, len(prefix), prefix)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove %s from vault database: %w", path, err)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStorage) ListFiles(dir string) ([]string, error) {
+	files, _, err := s.list(dir)
+	return files, err
+}
+
+func (s *SQLiteStorage) ListDirs(dir string) ([]string, error) {
+	_, dirs, err := s.list(dir)
+	return dirs, err
+}
+
+func (s *SQLiteStorage) list(dir string) ([]string, []string, error) {
+	key, err := relativeKey(s.root, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys, err := s.keysWithin(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list %s in vault database: %w", dir, err)
+	}
+
+	files, dirs := listChildren(s.root, key, keys)
+	return files, dirs, nil
+}
+
+// keysWithin returns every key at or below dir
+func (s *SQLiteStorage) keysWithin(dir string) ([]string, error) {
+	var rows *sql.Rows
+	var err error
+	if dir == "." {
+		rows, err = s.db.Query("SELECT path FROM files")
+	} else {
+		prefix := dir + "/"
+		rows, err = s.db.Query("SELECT path FROM files WHERE path = ? OR substr(path, 1, ?) = ?",
+			dir, len(prefix), prefix)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (s *SQLiteStorage) Exists(path string) bool {
+	key, err := relativeKey(s.root, path)
+	if err != nil {
+		return false
+	}
+
+	keys, err := s.keysWithin(key)
+	return err == nil && len(keys) > 0
+}
+
+func (s *SQLiteStorage) Batch(fn func(Storage) error) error {
+	batch := &batchStorage{base: s}
+	if err := fn(batch); err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin vault database transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, op := range batch.ops {
+		if op.remove {
+			err = s.remove(tx, op.path)
+		} else {
+			err = s.write(tx, op.path, op.data, op.perm)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit vault database transaction: %w", err)
+	}
+
+	return nil
+}
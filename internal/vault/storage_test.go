@@ -0,0 +1,249 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// testStorages returns an empty vault root and storage for every backend
+func testStorages(t *testing.T) map[string]func(t *testing.T) (string, Storage) {
+	return map[string]func(t *testing.T) (string, Storage){
+		StorageFS: func(t *testing.T) (string, Storage) {
+			root := filepath.Join(t.TempDir(), NvoltDir)
+			return root, NewFSStorage(root)
+		},
+		StorageMemory: func(t *testing.T) (string, Storage) {
+			root := filepath.Join(t.TempDir(), NvoltDir)
+			return root, NewMemoryStorage(root)
+		},
+		StorageSQLite: func(t *testing.T) (string, Storage) {
+			root := filepath.Join(t.TempDir(), NvoltDir)
+			s, err := OpenSQLiteStorage(filepath.Join(root, SQLiteFile), root)
+			if err != nil {
+				t.Fatalf("OpenSQLiteStorage failed: %v", err)
+			}
+			t.Cleanup(func() { s.Close() })
+			return root, s
+		},
+	}
+}
+
+func TestStorageContract(t *testing.T) {
+	for name, newStorage := range testStorages(t) {
+		t.Run(name, func(t *testing.T) {
+			root, s := newStorage(t)
+			secrets := filepath.Join(root, "secrets")
+
+			files := map[string]string{
+				filepath.Join(secrets, "dev", "B.enc.json"):  "b",
+				filepath.Join(secrets, "dev", "A.enc.json"):  "a",
+				filepath.Join(secrets, "prod", "A.enc.json"): "prod-a",
+				filepath.Join(secrets, "top.json"):           "top",
+			}
+			for path, data := range files {
+				if err := s.WriteFile(path, []byte(data), FilePerm); err != nil {
+					t.Fatalf("WriteFile failed: %v", err)
+				}
+			}
+
+			data, err := s.ReadFile(filepath.Join(secrets, "dev", "A.enc.json"))
+			if err != nil || string(data) != "a" {
+				t.Fatalf("ReadFile = %q, %v; want \"a\"", data, err)
+			}
+
+			if _, err := s.ReadFile(filepath.Join(secrets, "missing.json")); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("ReadFile of a missing file should fail with fs.ErrNotExist, got %v", err)
+			}
+
+			gotFiles, err := s.ListFiles(filepath.Join(secrets, "dev"))
+			wantFiles := []string{filepath.Join(secrets, "dev", "A.enc.json"), filepath.Join(secrets, "dev", "B.enc.json")}
+			if err != nil || !reflect.DeepEqual(gotFiles, wantFiles) {
+				t.Errorf("ListFiles = %v, %v; want %v", gotFiles, err, wantFiles)
+			}
+
+			gotDirs, err := s.ListDirs(secrets)
+			wantDirs := []string{filepath.Join(secrets, "dev"), filepath.Join(secrets, "prod")}
+			if err != nil || !reflect.DeepEqual(gotDirs, wantDirs) {
+				t.Errorf("ListDirs = %v, %v; want %v", gotDirs, err, wantDirs)
+			}
+
+			if empty, err := s.ListFiles(filepath.Join(secrets, "missing")); err != nil || len(empty) != 0 {
+				t.Errorf("ListFiles of a missing directory = %v, %v; want empty", empty, err)
+			}
+
+			if !s.Exists(filepath.Join(secrets, "dev")) || !s.Exists(filepath.Join(secrets, "top.json")) {
+				t.Error("Exists should report files and directories")
+			}
+
+			// Overwrite replaces the contents
+			if err := s.WriteFile(filepath.Join(secrets, "top.json"), []byte("new"), FilePerm); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+			if data, _ := s.ReadFile(filepath.Join(secrets, "top.json")); string(data) != "new" {
+				t.Errorf("ReadFile after overwrite = %q, want \"new\"", data)
+			}
+
+			// Removing a directory removes the tree, but not its siblings with a common prefix
+			if err := s.WriteFile(filepath.Join(secrets, "dev2", "C.enc.json"), []byte("c"), FilePerm); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+			if err := s.Remove(filepath.Join(secrets, "dev")); err != nil {
+				t.Fatalf("Remove failed: %v", err)
+			}
+			if s.Exists(filepath.Join(secrets, "dev")) || s.Exists(filepath.Join(secrets, "dev", "A.enc.json")) {
+				t.Error("Remove should remove the whole directory")
+			}
+			if !s.Exists(filepath.Join(secrets, "dev2", "C.enc.json")) {
+				t.Error("Remove should not touch sibling directories")
+			}
+
+			if err := s.Remove(filepath.Join(secrets, "missing")); err != nil {
+				t.Errorf("Remove of a missing path should succeed, got %v", err)
+			}
+
+			if _, err := s.ReadFile(filepath.Join(filepath.Dir(root), "outside.json")); err == nil {
+				t.Error("Paths outside the vault should be rejected")
+			}
+		})
+	}
+}
+
+func TestStorageBatch(t *testing.T) {
+	for name, newStorage := range testStorages(t) {
+		t.Run(name, func(t *testing.T) {
+			root, s := newStorage(t)
+			keep := filepath.Join(root, "keep.json")
+			removed := filepath.Join(root, "env", "removed.json")
+
+			for _, path := range []string{keep, removed} {
+				if err := s.WriteFile(path, []byte("old"), FilePerm); err != nil {
+					t.Fatalf("WriteFile failed: %v", err)
+				}
+			}
+
+			// A failing batch applies nothing
+			err := s.Batch(func(b Storage) error {
+				if err := b.WriteFile(keep, []byte("new"), FilePerm); err != nil {
+					return err
+				}
+				return fmt.Errorf("abort")
+			})
+			if err == nil {
+				t.Fatal("Batch should return the error of fn")
+			}
+			if data, _ := s.ReadFile(keep); string(data) != "old" {
+				t.Errorf("Failed batch should not apply writes, got %q", data)
+			}
+
+			err = s.Batch(func(b Storage) error {
+				if err := b.WriteFile(keep, []byte("new"), FilePerm); err != nil {
+					return err
+				}
+				if err := b.WriteFile(filepath.Join(root, "added", "file.json"), []byte("added"), FilePerm); err != nil {
+					return err
+				}
+				if err := b.Remove(filepath.Join(root, "env")); err != nil {
+					return err
+				}
+
+				// Reads see the state before the batch
+				if data, _ := b.ReadFile(keep); string(data) != "old" {
+					t.Errorf("Reads inside a batch should see the previous state, got %q", data)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Batch failed: %v", err)
+			}
+
+			if data, _ := s.ReadFile(keep); string(data) != "new" {
+				t.Errorf("Batch write not applied, got %q", data)
+			}
+			if data, _ := s.ReadFile(filepath.Join(root, "added", "file.json")); string(data) != "added" {
+				t.Errorf("Batch write not applied, got %q", data)
+			}
+			if s.Exists(removed) {
+				t.Error("Batch removal not applied")
+			}
+		})
+	}
+}
+
+func TestVaultOnMemoryStorage(t *testing.T) {
+	root := filepath.Join(t.TempDir(), NvoltDir)
+	paths := GetVaultPaths(root, "").WithStorage(NewMemoryStorage(root))
+
+	newTestMachine(t, paths, "m-test")
+	masterKey := pushTestSecrets(t, paths, "dev", "m-test", map[string]string{"A": "1", "B": "2"})
+
+	if err := RenameEnvironment(paths, "dev", "staging"); err != nil {
+		t.Fatalf("RenameEnvironment failed: %v", err)
+	}
+
+	environments, err := ListEnvironments(paths)
+	if err != nil || !reflect.DeepEqual(environments, []string{"staging"}) {
+		t.Fatalf("ListEnvironments = %v, %v; want [staging]", environments, err)
+	}
+
+	encrypted, err := LoadEncryptedSecret(paths, "staging", "B")
+	if err != nil {
+		t.Fatalf("LoadEncryptedSecret failed: %v", err)
+	}
+	if value, err := DecryptSecret(masterKey, encrypted); err != nil || value != "2" {
+		t.Errorf("DecryptSecret = %q, %v; want \"2\"", value, err)
+	}
+
+	if machines, err := ListEnvironmentMachines(paths, "staging"); err != nil || !reflect.DeepEqual(machines, []string{"m-test"}) {
+		t.Errorf("ListEnvironmentMachines = %v, %v; want [m-test]", machines, err)
+	}
+
+	// Nothing touches the filesystem
+	if FileExists(root) {
+		t.Error("Memory storage should not write to disk")
+	}
+}
+
+func TestTransactionOnSQLiteStorage(t *testing.T) {
+	root := filepath.Join(t.TempDir(), NvoltDir)
+	storage, err := OpenSQLiteStorage(filepath.Join(root, SQLiteFile), root)
+	if err != nil {
+		t.Fatalf("OpenSQLiteStorage failed: %v", err)
+	}
+	defer storage.Close()
+
+	paths := GetVaultPaths(root, "").WithStorage(storage)
+	newTestMachine(t, paths, "m-test")
+	pushTestSecrets(t, paths, "old", "m-test", map[string]string{"A": "1"})
+
+	tx, err := beginTransaction(root, storage, "test")
+	if err != nil {
+		t.Fatalf("beginTransaction failed: %v", err)
+	}
+	defer tx.Rollback()
+
+	txPaths := paths.WithTransaction(tx)
+	pushTestSecrets(t, txPaths, "new", "m-test", map[string]string{"B": "2"})
+	if err := DeleteEnvironment(txPaths, "old"); err != nil {
+		t.Fatalf("DeleteEnvironment failed: %v", err)
+	}
+
+	if EnvironmentExists(paths, "new") {
+		t.Fatal("Staged changes should not be applied before commit")
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	environments, err := ListEnvironments(paths)
+	if err != nil || !reflect.DeepEqual(environments, []string{"new"}) {
+		t.Errorf("ListEnvironments = %v, %v; want [new]", environments, err)
+	}
+	if FileExists(filepath.Join(root, NvoltDir)) {
+		t.Error("SQLite vault should not write the directory tree")
+	}
+}
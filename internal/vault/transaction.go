@@ -0,0 +1,489 @@
+package vault
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// TransactionDir is the staging directory of an in-flight transaction
+	TransactionDir = "nvolt-tx"
+
+	// journalFile is written when a transaction commits; its presence means
+	// the staged changes must be applied
+	journalFile = "journal.json"
+
+	txOpWrite  = "write"
+	txOpRemove = "remove"
+)
+
+// Transaction stages vault changes and applies them all at once.
+//
+// Writes go to a staging directory first. Commit records every operation in a
+// journal and only then touches the vault, so a crash before the journal is
+// written leaves the vault untouched (rolled back) and a crash after it is
+// finished by RecoverTransaction (rolled forward). Callers must hold the vault
+// lock. Reads are not affected and always see the committed vault state.
+//
+// A Transaction is itself a Storage whose writes and removals are staged.
+type Transaction struct {
+	vaultPath   string
+	base        Storage
+	dir         string
+	description string
+	ops         []txOp
+	done        bool
+}
+
+// txJournal is the on-disk record of a committed transaction
+type txJournal struct {
+	Description string    `json:"description"`
+	PID         int       `json:"pid"`
+	CommittedAt time.Time `json:"committed_at"`
+	Ops         []txOp    `json:"ops"`
+}
+
+// txOp is a single staged operation. Path is relative to the vault root.
+type txOp struct {
+	Op     string      `json:"op"`
+	Path   string      `json:"path"`
+	Staged string      `json:"staged,omitempty"`
+	Perm   fs.FileMode `json:"perm,omitempty"`
+}
+
+// RecoveryResult describes what RecoverTransaction did with an interrupted transaction
+type RecoveryResult struct {
+	Description string
+	RolledBack  bool // Not committed: staged changes were discarded
+	Operations  int  // Committed: number of operations re-applied
+}
+
+// GetTransactionPath returns the staging directory for a vault's transactions
+func GetTransactionPath(vaultPath string) string {
+	return filepath.Join(getStateDir(vaultPath), TransactionDir)
+}
+
+// BeginTransaction starts a transaction on the vault.
+// Fails if an interrupted transaction has not been recovered yet.
+func BeginTransaction(vaultPath, description string) (*Transaction, error) {
+	return beginTransaction(vaultPath, storageFor(vaultPath), description)
+}
+
+// beginTransaction starts a transaction that is applied to base on commit
+func beginTransaction(vaultPath string, base Storage, description string) (*Transaction, error) {
+	dir := GetTransactionPath(vaultPath)
+	if FileExists(dir) {
+		return nil, fmt.Errorf("an interrupted transaction is pending in %s: run any nvolt command that modifies the vault to recover it", dir)
+	}
+
+	if err := ensureDir(filepath.Join(dir, "staged"), DirPerm); err != nil {
+		return nil, fmt.Errorf("failed to create transaction directory: %w", err)
+	}
+
+	return &Transaction{vaultPath: vaultPath, base: base, dir: dir, description: description}, nil
+}
+
+// WriteFile stages a file write
+func (tx *Transaction) WriteFile(path string, data []byte, perm fs.FileMode) error {
+	rel, err := tx.relPath(path)
+	if err != nil {
+		return err
+	}
+
+	staged := strconv.Itoa(len(tx.ops))
+	if err := WriteFileAtomic(filepath.Join(tx.dir, "staged", staged), data, perm); err != nil {
+		return fmt.Errorf("failed to stage %s: %w", rel, err)
+	}
+
+	tx.ops = append(tx.ops, txOp{Op: txOpWrite, Path: rel, Staged: staged, Perm: perm})
+	return nil
+}
+
+// Remove stages the removal of a file or directory
+func (tx *Transaction) Remove(path string) error {
+	rel, err := tx.relPath(path)
+	if err != nil {
+		return err
+	}
+
+	tx.ops = append(tx.ops, txOp{Op: txOpRemove, Path: rel})
+	return nil
+}
+
+// Commit writes the journal and applies the staged operations.
+// If applying fails part way, the journal is kept so the next
+// RecoverTransaction finishes the job.
+func (tx *Transaction) Commit() error {
+	if tx.done {
+		return fmt.Errorf("transaction already finished")
+	}
+	tx.done = true
+
+	journal, err := tx.writeJournal()
+	if err != nil {
+		os.RemoveAll(tx.dir)
+		return err
+	}
+
+	return applyJournal(tx.base, tx.vaultPath, tx.dir, journal)
+}
+
+// writeJournal records the staged operations. Once it returns, the
+// transaction is committed.
+func (tx *Transaction) writeJournal() (*txJournal, error) {
+	journal := &txJournal{
+		Description: tx.description,
+		PID:         os.Getpid(),
+		CommittedAt: time.Now(),
+		Ops:         tx.ops,
+	}
+
+	data, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal transaction journal: %w", err)
+	}
+
+	if err := WriteFileAtomic(filepath.Join(tx.dir, journalFile), data, FilePerm); err != nil {
+		return nil, fmt.Errorf("failed to write transaction journal: %w", err)
+	}
+
+	return journal, nil
+}
+
+// Rollback discards the staged operations. It is a no-op after Commit,
+// so it can be deferred right after BeginTransaction.
+func (tx *Transaction) Rollback() error {
+	if tx.done {
+		return nil
+	}
+	tx.done = true
+
+	return os.RemoveAll(tx.dir)
+}
+
+// Len returns the number of staged operations
+func (tx *Transaction) Len() int {
+	return len(tx.ops)
+}
+
+// relPath converts a vault path to a journal path, rejecting paths outside the vault
+func (tx *Transaction) relPath(path string) (string, error) {
+	return relativeKey(tx.vaultPath, path)
+}
+
+// ReadFile reads from the committed vault state
+func (tx *Transaction) ReadFile(path string) ([]byte, error) {
+	return tx.base.ReadFile(path)
+}
+
+// ListFiles lists files in the committed vault state
+func (tx *Transaction) ListFiles(dir string) ([]string, error) {
+	return tx.base.ListFiles(dir)
+}
+
+// ListDirs lists directories in the committed vault state
+func (tx *Transaction) ListDirs(dir string) ([]string, error) {
+	return tx.base.ListDirs(dir)
+}
+
+// Exists checks the committed vault state
+func (tx *Transaction) Exists(path string) bool {
+	return tx.base.Exists(path)
+}
+
+// Batch runs fn within the transaction
+func (tx *Transaction) Batch(fn func(Storage) error) error {
+	return fn(tx)
+}
+
+// WithTransaction returns a copy of the paths whose writes and removals are
+// staged in tx instead of being applied to the vault directly
+func (p *Paths) WithTransaction(tx *Transaction) *Paths {
+	return p.WithStorage(tx)
+}
+
+// Staged returns a Storage that reads the vault as it will be once tx is
+// committed, so staged changes can be checked before committing them.
+// Writes and removals are staged in tx.
+func (tx *Transaction) Staged() Storage {
+	return &stagedStorage{tx: tx}
+}
+
+// stagedStorage overlays the staged operations of a transaction on its base
+type stagedStorage struct {
+	tx *Transaction
+}
+
+// lookup returns the last staged operation affecting the file rel, if any
+func (s *stagedStorage) lookup(rel string) (txOp, bool) {
+	for i := len(s.tx.ops) - 1; i >= 0; i-- {
+		op := s.tx.ops[i]
+		if op.Path == rel || (op.Op == txOpRemove && isUnder(rel, op.Path)) {
+			return op, true
+		}
+	}
+	return txOp{}, false
+}
+
+// dirState reports whether staged operations create (true) or remove (false)
+// the directory rel, and whether any operation decides it
+func (s *stagedStorage) dirState(rel string) (bool, bool) {
+	for i := len(s.tx.ops) - 1; i >= 0; i-- {
+		op := s.tx.ops[i]
+		switch {
+		case op.Op == txOpWrite && isUnder(op.Path, rel):
+			return true, true
+		case op.Op == txOpRemove && (op.Path == rel || isUnder(rel, op.Path)):
+			return false, true
+		}
+	}
+	return false, false
+}
+
+func (s *stagedStorage) ReadFile(path string) ([]byte, error) {
+	rel, err := s.tx.relPath(path)
+	if err != nil {
+		return nil, err
+	}
+	op, ok := s.lookup(rel)
+	if !ok {
+		return s.tx.base.ReadFile(path)
+	}
+	if op.Op == txOpRemove {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	return os.ReadFile(filepath.Join(s.tx.dir, "staged", op.Staged))
+}
+
+func (s *stagedStorage) WriteFile(path string, data []byte, perm fs.FileMode) error {
+	return s.tx.WriteFile(path, data, perm)
+}
+
+func (s *stagedStorage) Remove(path string) error {
+	return s.tx.Remove(path)
+}
+
+func (s *stagedStorage) ListFiles(dir string) ([]string, error) {
+	rel, err := s.tx.relPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	files, err := s.tx.base.ListFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range s.tx.ops {
+		if name, ok := childName(op.Path, rel); ok && op.Op == txOpWrite && !strings.Contains(name, "/") {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	return s.existing(files, s.Exists), nil
+}
+
+func (s *stagedStorage) ListDirs(dir string) ([]string, error) {
+	rel, err := s.tx.relPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	dirs, err := s.tx.base.ListDirs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range s.tx.ops {
+		if name, ok := childName(op.Path, rel); ok && op.Op == txOpWrite {
+			if name, _, nested := strings.Cut(name, "/"); nested {
+				dirs = append(dirs, filepath.Join(dir, name))
+			}
+		}
+	}
+	return s.existing(dirs, s.Exists), nil
+}
+
+// existing sorts paths and drops duplicates and paths that no longer exist
+func (s *stagedStorage) existing(paths []string, exists func(string) bool) []string {
+	sort.Strings(paths)
+	result := paths[:0]
+	for i, path := range paths {
+		if (i == 0 || path != paths[i-1]) && exists(path) {
+			result = append(result, path)
+		}
+	}
+	return result
+}
+
+func (s *stagedStorage) Exists(path string) bool {
+	rel, err := s.tx.relPath(path)
+	if err != nil {
+		return false
+	}
+	if op, ok := s.lookup(rel); ok {
+		if op.Op == txOpWrite {
+			return true
+		}
+		// A removed directory may have been written to again
+		created, _ := s.dirState(rel)
+		return created
+	}
+	if created, ok := s.dirState(rel); ok {
+		return created
+	}
+	return s.tx.base.Exists(path)
+}
+
+func (s *stagedStorage) Batch(fn func(Storage) error) error {
+	return fn(s)
+}
+
+// isUnder reports whether the journal path rel is inside the directory dir
+func isUnder(rel, dir string) bool {
+	_, ok := childName(rel, dir)
+	return ok
+}
+
+// childName returns the path of rel relative to the directory dir
+func childName(rel, dir string) (string, bool) {
+	if dir == "." {
+		return rel, rel != "."
+	}
+	return strings.CutPrefix(rel, dir+"/")
+}
+
+// RecoverTransaction finishes or discards a transaction interrupted by a crash.
+// Committed transactions are applied; uncommitted ones are rolled back.
+// Returns nil if there was nothing to recover. Callers must hold the vault lock.
+func RecoverTransaction(vaultPath string) (*RecoveryResult, error) {
+	dir := GetTransactionPath(vaultPath)
+	if !FileExists(dir) {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, journalFile))
+	if os.IsNotExist(err) {
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, fmt.Errorf("failed to discard interrupted transaction: %w", err)
+		}
+		return &RecoveryResult{RolledBack: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transaction journal: %w", err)
+	}
+
+	var journal txJournal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return nil, fmt.Errorf("failed to parse transaction journal: %w", err)
+	}
+
+	storage, err := StorageFor(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyJournal(storage, vaultPath, dir, &journal); err != nil {
+		return nil, err
+	}
+
+	return &RecoveryResult{Description: journal.Description, Operations: len(journal.Ops)}, nil
+}
+
+// applyJournal applies committed operations in order and removes the
+// transaction directory. It is idempotent: a staged file that no longer exists
+// has already been moved into place, and a remove is only replayed until its
+// done marker has been written.
+func applyJournal(storage Storage, vaultPath, dir string, journal *txJournal) error {
+	if err := applyOps(storage, vaultPath, dir, journal.Ops); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clean up transaction: %w", err)
+	}
+
+	return nil
+}
+
+// applyOps applies journal operations in order. Staged files are renamed into
+// a filesystem vault; other storages get all operations in a single batch,
+// which can simply be replayed if it is interrupted.
+func applyOps(storage Storage, vaultPath, dir string, ops []txOp) error {
+	if _, ok := storage.(*FSStorage); !ok {
+		return storage.Batch(func(batch Storage) error {
+			return replayOps(batch, vaultPath, dir, ops)
+		})
+	}
+
+	for i, op := range ops {
+		target := filepath.Join(vaultPath, filepath.FromSlash(op.Path))
+
+		switch op.Op {
+		case txOpWrite:
+			staged := filepath.Join(dir, "staged", op.Staged)
+			if !FileExists(staged) {
+				continue
+			}
+			if err := ensureDir(filepath.Dir(target), DirPerm); err != nil {
+				return fmt.Errorf("failed to apply transaction: %w", err)
+			}
+			if err := os.Rename(staged, target); err != nil {
+				return fmt.Errorf("failed to apply transaction: %w", err)
+			}
+
+		case txOpRemove:
+			marker := filepath.Join(dir, "staged", fmt.Sprintf("%d.done", i))
+			if FileExists(marker) {
+				continue
+			}
+			if err := os.RemoveAll(target); err != nil {
+				return fmt.Errorf("failed to apply transaction: %w", err)
+			}
+			if err := os.WriteFile(marker, nil, FilePerm); err != nil {
+				return fmt.Errorf("failed to apply transaction: %w", err)
+			}
+
+		default:
+			return fmt.Errorf("unknown transaction operation '%s'", op.Op)
+		}
+	}
+
+	return nil
+}
+
+// replayOps copies journal operations into a storage
+func replayOps(storage Storage, vaultPath, dir string, ops []txOp) error {
+	for _, op := range ops {
+		target := filepath.Join(vaultPath, filepath.FromSlash(op.Path))
+
+		switch op.Op {
+		case txOpWrite:
+			data, err := os.ReadFile(filepath.Join(dir, "staged", op.Staged))
+			if err != nil {
+				return fmt.Errorf("failed to apply transaction: %w", err)
+			}
+			perm := op.Perm
+			if perm == 0 {
+				perm = FilePerm
+			}
+			if err := storage.WriteFile(target, data, perm); err != nil {
+				return fmt.Errorf("failed to apply transaction: %w", err)
+			}
+
+		case txOpRemove:
+			if err := storage.Remove(target); err != nil {
+				return fmt.Errorf("failed to apply transaction: %w", err)
+			}
+
+		default:
+			return fmt.Errorf("unknown transaction operation '%s'", op.Op)
+		}
+	}
+
+	return nil
+}
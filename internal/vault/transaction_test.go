@@ -0,0 +1,194 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/iluxav/nvolt/internal/crypto"
+)
+
+func TestTransactionCommit(t *testing.T) {
+	paths, _ := newTestVault(t)
+	pushTestSecrets(t, paths, "old", "m-test", map[string]string{"A": "1"})
+
+	tx, err := BeginTransaction(paths.Root, "test")
+	if err != nil {
+		t.Fatalf("BeginTransaction failed: %v", err)
+	}
+	defer tx.Rollback()
+
+	txPaths := paths.WithTransaction(tx)
+	pushTestSecrets(t, txPaths, "default", "m-test", map[string]string{"B": "2"})
+	if err := DeleteEnvironment(txPaths, "old"); err != nil {
+		t.Fatalf("DeleteEnvironment failed: %v", err)
+	}
+
+	// Nothing is visible before commit
+	if EnvironmentExists(paths, "default") || !EnvironmentExists(paths, "old") {
+		t.Fatal("Staged changes should not be applied before commit")
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+
+	if !FileExists(paths.GetSecretFilePath("default", "B")) || !FileExists(paths.GetWrappedKeyPath("default", "m-test")) {
+		t.Error("Staged writes should be applied after commit")
+	}
+	if EnvironmentExists(paths, "old") {
+		t.Error("Staged removal should be applied after commit")
+	}
+	if FileExists(GetTransactionPath(paths.Root)) {
+		t.Error("Transaction directory should be removed after commit")
+	}
+}
+
+func TestTransactionStaged(t *testing.T) {
+	paths, _ := newTestVault(t)
+	pushTestSecrets(t, paths, "old", "m-test", map[string]string{"A": "1"})
+
+	tx, err := BeginTransaction(paths.Root, "test")
+	if err != nil {
+		t.Fatalf("BeginTransaction failed: %v", err)
+	}
+	defer tx.Rollback()
+
+	txPaths := paths.WithTransaction(tx)
+	pushTestSecrets(t, txPaths, "default", "m-test", map[string]string{"B": "2", "C": "3"})
+	if err := DeleteEnvironment(txPaths, "old"); err != nil {
+		t.Fatalf("DeleteEnvironment failed: %v", err)
+	}
+
+	// The staged view sees the vault as it will be after commit
+	staged := paths.WithStorage(tx.Staged())
+	if !EnvironmentExists(staged, "default") || EnvironmentExists(staged, "old") {
+		t.Error("Staged view should include staged writes and removals")
+	}
+	envs, err := ListEnvironments(staged)
+	if err != nil || len(envs) != 1 || envs[0] != "default" {
+		t.Errorf("ListEnvironments(staged) = %v, %v, want [default]", envs, err)
+	}
+	files, err := staged.Storage().ListFiles(staged.GetSecretsPath("default"))
+	if err != nil || len(files) != 2 {
+		t.Errorf("ListFiles(staged) = %v, %v, want 2 files", files, err)
+	}
+	if _, err := staged.Storage().ReadFile(paths.GetSecretFilePath("old", "A")); err == nil {
+		t.Error("Removed file should not be readable in the staged view")
+	}
+	if _, err := LoadEncryptedSecret(staged, "default", "B"); err != nil {
+		t.Errorf("Staged secret should be readable: %v", err)
+	}
+
+	// The vault itself is unchanged until commit
+	if EnvironmentExists(paths, "default") || !EnvironmentExists(paths, "old") {
+		t.Error("Staged changes should not be applied before commit")
+	}
+}
+
+func TestTransactionRollback(t *testing.T) {
+	paths, _ := newTestVault(t)
+
+	tx, err := BeginTransaction(paths.Root, "test")
+	if err != nil {
+		t.Fatalf("BeginTransaction failed: %v", err)
+	}
+	pushTestSecrets(t, paths.WithTransaction(tx), "default", "m-test", map[string]string{"A": "1"})
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	if EnvironmentExists(paths, "default") {
+		t.Error("Rolled back changes should not be applied")
+	}
+	if FileExists(GetTransactionPath(paths.Root)) {
+		t.Error("Transaction directory should be removed after rollback")
+	}
+}
+
+func TestRecoverUncommittedTransaction(t *testing.T) {
+	paths, _ := newTestVault(t)
+
+	// Simulate a crash before commit
+	tx, err := BeginTransaction(paths.Root, "test")
+	if err != nil {
+		t.Fatalf("BeginTransaction failed: %v", err)
+	}
+	pushTestSecrets(t, paths.WithTransaction(tx), "default", "m-test", map[string]string{"A": "1"})
+
+	if _, err := BeginTransaction(paths.Root, "other"); err == nil {
+		t.Error("Expected error when an interrupted transaction is pending")
+	}
+
+	result, err := RecoverTransaction(paths.Root)
+	if err != nil {
+		t.Fatalf("RecoverTransaction failed: %v", err)
+	}
+	if result == nil || !result.RolledBack {
+		t.Fatalf("Expected uncommitted transaction to be rolled back, got %+v", result)
+	}
+	if EnvironmentExists(paths, "default") {
+		t.Error("Uncommitted changes should not be applied")
+	}
+
+	// Nothing left to recover
+	if result, err := RecoverTransaction(paths.Root); err != nil || result != nil {
+		t.Errorf("Expected nothing to recover, got %+v, %v", result, err)
+	}
+}
+
+func TestRecoverCommittedTransaction(t *testing.T) {
+	paths, _ := newTestVault(t)
+	pushTestSecrets(t, paths, "default", "m-test", map[string]string{"A": "1"})
+
+	// Simulate a crash after the journal was written but before it was applied
+	tx, err := BeginTransaction(paths.Root, "rotate")
+	if err != nil {
+		t.Fatalf("BeginTransaction failed: %v", err)
+	}
+	txPaths := paths.WithTransaction(tx)
+	if err := DeleteEnvironment(txPaths, "default"); err != nil {
+		t.Fatalf("DeleteEnvironment failed: %v", err)
+	}
+	newKey, err := crypto.GenerateAESKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	encrypted, err := EncryptSecret(newKey, "2")
+	if err != nil {
+		t.Fatalf("Failed to encrypt secret: %v", err)
+	}
+	if err := SaveEncryptedSecret(txPaths, "default", "A", encrypted); err != nil {
+		t.Fatalf("Failed to save secret: %v", err)
+	}
+	if err := WrapMasterKeyForMachines(txPaths, "default", newKey, "m-test", true); err != nil {
+		t.Fatalf("Failed to wrap key: %v", err)
+	}
+	journal, err := tx.writeJournal()
+	if err != nil {
+		t.Fatalf("Failed to write journal: %v", err)
+	}
+
+	// Apply part of the journal, then crash again
+	if err := applyOps(NewFSStorage(paths.Root), paths.Root, tx.dir, journal.Ops[:3]); err != nil {
+		t.Fatalf("Partial apply failed: %v", err)
+	}
+
+	result, err := RecoverTransaction(paths.Root)
+	if err != nil {
+		t.Fatalf("RecoverTransaction failed: %v", err)
+	}
+	if result == nil || result.RolledBack || result.Description != "rotate" {
+		t.Fatalf("Expected committed transaction to be completed, got %+v", result)
+	}
+
+	encrypted, err = LoadEncryptedSecret(paths, "default", "A")
+	if err != nil {
+		t.Fatalf("Secret should exist after recovery: %v", err)
+	}
+	if value, err := DecryptSecret(newKey, encrypted); err != nil || value != "2" {
+		t.Errorf("Expected secret to be encrypted with the new key, got %q, %v", value, err)
+	}
+	if !FileExists(paths.GetWrappedKeyPath("default", "m-test")) {
+		t.Error("Wrapped key should exist after recovery")
+	}
+}
@@ -40,7 +40,8 @@ type KeyInfo struct {
 
 // VaultConfig represents the vault configuration
 type VaultConfig struct {
-	Mode       string `json:"mode"`        // "local" or "global"
-	Repository string `json:"repository"`  // GitHub repo (org/repo) for global mode
-	Project    string `json:"project"`     // Project name
+	SchemaVersion int    `json:"schema_version"` // Vault format version
+	Mode          string `json:"mode"`           // "local" or "global"
+	Repository    string `json:"repository"`     // GitHub repo (org/repo) for global mode
+	Project       string `json:"project"`        // Project name
 }